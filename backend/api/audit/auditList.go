@@ -0,0 +1,60 @@
+package apiAudit
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runny-code/audit"
+	"strconv"
+	"time"
+)
+
+// GetAuditListHandle returns audit entries newest first
+// Query params: actor, action, target, result, since, until (RFC 3339) and limit
+func GetAuditListHandle(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := audit.Filter{
+		Actor:  query.Get("actor"),
+		Action: query.Get("action"),
+		Target: query.Get("target"),
+		Result: query.Get("result"),
+	}
+
+	var err error
+	if since := query.Get("since"); since != "" {
+		filter.Since, err = time.Parse(time.RFC3339, since)
+		if err != nil {
+			http.Error(w, "Invalid 'since', expected RFC 3339 time", http.StatusBadRequest)
+			return
+		}
+	}
+	if until := query.Get("until"); until != "" {
+		filter.Until, err = time.Parse(time.RFC3339, until)
+		if err != nil {
+			http.Error(w, "Invalid 'until', expected RFC 3339 time", http.StatusBadRequest)
+			return
+		}
+	}
+	if limit := query.Get("limit"); limit != "" {
+		filter.Limit, err = strconv.Atoi(limit)
+		if err != nil || filter.Limit < 0 {
+			http.Error(w, "Invalid 'limit'", http.StatusBadRequest)
+			return
+		}
+	}
+
+	entries, err := audit.Query(filter)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to read audit log: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	entriesByte, err := json.Marshal(entries)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to json marshal audit entries: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(entriesByte)
+}
@@ -2,24 +2,21 @@ package apiAuth
 
 import (
 	"net/http"
-	"runny-code/common"
+	apiMiddleware "runny-code/api/middleware"
+	"runny-code/sessions"
 )
 
 func IsAuthenticatedHandle(w http.ResponseWriter, r *http.Request) {
-	cookie, err := r.Cookie("session")
+	cookie, err := r.Cookie(apiMiddleware.SessionCookieName)
 	if err != nil {
 		http.Error(w, "Unauthorized: No session cookie", http.StatusUnauthorized)
 		return
 	}
 
-	if !isValidSession(cookie.Value) {
+	if _, _, valid := sessions.Authenticate(cookie.Value); !valid {
 		http.Error(w, "Unauthorized: Invalid session", http.StatusUnauthorized)
 		return
 	}
 
 	w.Write([]byte("Authenticated"))
 }
-
-func isValidSession(token string) bool {
-	return token == common.SessionToken
-}
@@ -1,25 +1,97 @@
 package apiAuth
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/http"
+	apiMiddleware "runny-code/api/middleware"
+	"runny-code/audit"
 	"runny-code/common"
+	"runny-code/identity"
+	"runny-code/sessions"
+	"runny-code/settings"
+	"runny-code/users"
 )
 
+// TwoFactorResponse asks the client for the second login step at `POST /login/2fa`
+type TwoFactorResponse struct {
+	TwoFactor string `json:"twoFactor"` // verify | enroll
+	Challenge string `json:"challenge"`
+	Secret    string `json:"secret,omitempty"` // for enroll
+	URI       string `json:"uri,omitempty"`    // otpauth:// URI for enroll, to show as a QR code
+}
+
 func LoginHandle(w http.ResponseWriter, r *http.Request) {
 	pass := r.FormValue("password")
 	user := r.FormValue("username")
 
-	if pass != common.Password_Env || user != common.Username_Env {
+	if !checkThrottle(w, r, user) {
+		return
+	}
+
+	foundUser, err := users.Authenticate(user, pass)
+	if err != nil {
+		auditLogin(r, user, err)
+		recordFailure(r, user)
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
 
-	http.SetCookie(w, &http.Cookie{
-		Name:     "session",
-		Value:    common.SessionToken,
-		Path:     "/",
-		HttpOnly: true,
-	})
+	// Users with 2FA, or without it when it is mandatory, get a challenge instead of a session
+	response := TwoFactorResponse{}
+	if foundUser.TOTPEnabled {
+		response.TwoFactor = sessions.ChallengeVerify
+	} else if settings.Current().Require2FA {
+		response.TwoFactor = sessions.ChallengeEnroll
+		response.Secret, response.URI, err = users.BeginTOTPEnrollment(foundUser.Username)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to start 2FA enrollment: %s", err.Error()), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if response.TwoFactor != "" {
+		response.Challenge, err = sessions.CreateChallenge(foundUser.Username, response.TwoFactor)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to create login challenge: %s", err.Error()), http.StatusInternalServerError)
+			return
+		}
+
+		responseByte, err := json.Marshal(response)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to json marshal challenge: %s", err.Error()), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(responseByte)
+		return
+	}
+
+	if !startSession(w, r, foundUser.Username) {
+		return
+	}
+	auditLogin(r, user, nil)
+	recordSuccess(user)
+
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("Login successful"))
 }
+
+// startSession creates a session and sets its cookie, responds with the error on failure
+func startSession(w http.ResponseWriter, r *http.Request, username string) bool {
+	_, token, err := sessions.Create(username, r.UserAgent(), common.ClientIP(r))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to create session: %s", err.Error()), http.StatusInternalServerError)
+		return false
+	}
+
+	apiMiddleware.SetSessionCookies(w, r, token)
+	return true
+}
+
+// auditLogin records a login attempt, the request has no identity yet so the attempted username is the actor
+func auditLogin(r *http.Request, username string, err error) {
+	r = r.WithContext(identity.WithIdentity(r.Context(), identity.Identity{Username: username}))
+	audit.Record(r, "auth.login", username, err, nil)
+}
@@ -0,0 +1,24 @@
+package apiAuth
+
+import (
+	"encoding/json"
+	"net/http"
+	"runny-code/sso"
+)
+
+// LoginMethods tells the login page which ways to sign in are available
+type LoginMethods struct {
+	Password bool `json:"password"`
+	OIDC     bool `json:"oidc"`
+}
+
+func LoginMethodsHandle(w http.ResponseWriter, r *http.Request) {
+	methodsByte, err := json.Marshal(LoginMethods{Password: true, OIDC: sso.Enabled()})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(methodsByte)
+}
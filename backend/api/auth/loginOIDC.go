@@ -0,0 +1,106 @@
+package apiAuth
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	apiMiddleware "runny-code/api/middleware"
+	"runny-code/audit"
+	"runny-code/common"
+	"runny-code/identity"
+	"runny-code/sso"
+	"runny-code/users"
+)
+
+// LoginOIDCHandle redirects to the identity provider to sign in with single sign-on
+func LoginOIDCHandle(w http.ResponseWriter, r *http.Request) {
+	if !sso.Enabled() {
+		http.Error(w, sso.ErrDisabled.Error(), http.StatusNotFound)
+		return
+	}
+
+	state, authURL, err := sso.Begin(oidcRedirectURL(r))
+	if err != nil {
+		oidcFailed(w, r, "", err)
+		return
+	}
+
+	apiMiddleware.SetOIDCStateCookie(w, r, state, 600)
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// LoginOIDCCallbackHandle completes the sign-in when the identity provider redirects back, then opens the web interface
+// The second factor is left to the identity provider, `require2FA` only applies to password logins
+func LoginOIDCCallbackHandle(w http.ResponseWriter, r *http.Request) {
+	if !sso.Enabled() {
+		http.Error(w, sso.ErrDisabled.Error(), http.StatusNotFound)
+		return
+	}
+
+	query := r.URL.Query()
+	state := query.Get("state")
+
+	cookie, err := r.Cookie(apiMiddleware.OIDCStateCookieName)
+	apiMiddleware.SetOIDCStateCookie(w, r, "", -1)
+	if err != nil || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(state)) != 1 {
+		oidcFailed(w, r, "", errors.New("the sign-in was not started in this browser"))
+		return
+	}
+
+	if providerError := query.Get("error"); providerError != "" {
+		sso.Cancel(state)
+		oidcFailed(w, r, "", fmt.Errorf("the identity provider refused the sign-in: %s %s", providerError, query.Get("error_description")))
+		return
+	}
+
+	ssoIdentity, err := sso.Complete(r.Context(), state, query.Get("code"))
+	if err != nil {
+		oidcFailed(w, r, "", err)
+		return
+	}
+
+	user, err := users.SignInExternal(users.ExternalAccount{
+		Provider: sso.Provider,
+		Issuer:   ssoIdentity.Issuer,
+		Subject:  ssoIdentity.Subject,
+		Username: ssoIdentity.Username,
+		Role:     ssoIdentity.Role,
+	})
+	if err != nil {
+		oidcFailed(w, r, ssoIdentity.Username, err)
+		return
+	}
+
+	if !startSession(w, r, user.Username) {
+		return
+	}
+	auditOIDCLogin(r, user.Username, nil)
+
+	http.Redirect(w, r, "/", http.StatusFound)
+}
+
+// oidcRedirectURL is `OIDC_REDIRECT_URL`, or the callback on the host of the request
+func oidcRedirectURL(r *http.Request) string {
+	if common.OIDC_Redirect_URL_Env != "" {
+		return common.OIDC_Redirect_URL_Env
+	}
+
+	scheme := "http"
+	if common.IsSecureRequest(r) {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + "/login/oidc/callback"
+}
+
+// oidcFailed records the failed sign-in and sends the browser back to the login page with the error
+func oidcFailed(w http.ResponseWriter, r *http.Request, username string, err error) {
+	auditOIDCLogin(r, username, err)
+	http.Redirect(w, r, "/auth/?error="+url.QueryEscape(err.Error()), http.StatusFound)
+}
+
+func auditOIDCLogin(r *http.Request, username string, err error) {
+	r = r.WithContext(identity.WithIdentity(r.Context(), identity.Identity{Username: username}))
+	audit.Record(r, "auth.login", username, err, map[string]string{"method": sso.Provider})
+}
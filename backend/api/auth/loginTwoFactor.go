@@ -0,0 +1,59 @@
+package apiAuth
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runny-code/sessions"
+	"runny-code/users"
+)
+
+type LoginTwoFactorResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"` // set after enrolling, shown once
+}
+
+// LoginTwoFactorHandle is the second login step, takes the challenge from `POST /login` and a TOTP or recovery code
+func LoginTwoFactorHandle(w http.ResponseWriter, r *http.Request) {
+	challengeToken := r.FormValue("challenge")
+	code := r.FormValue("code")
+
+	challenge, found := sessions.AttemptChallenge(challengeToken)
+	if !found {
+		http.Error(w, "Unauthorized: The login expired, enter your password again", http.StatusUnauthorized)
+		return
+	}
+	if !checkThrottle(w, r, challenge.Username) {
+		return
+	}
+
+	response := LoginTwoFactorResponse{RecoveryCodes: []string{}}
+	var err error
+	switch challenge.Kind {
+	case sessions.ChallengeVerify:
+		err = users.VerifySecondFactor(challenge.Username, code)
+	case sessions.ChallengeEnroll:
+		response.RecoveryCodes, err = users.ConfirmTOTP(challenge.Username, code)
+	}
+	if err != nil {
+		auditLogin(r, challenge.Username, err)
+		recordFailure(r, challenge.Username)
+		http.Error(w, "Unauthorized: Invalid code", http.StatusUnauthorized)
+		return
+	}
+
+	sessions.DeleteChallenge(challengeToken)
+	if !startSession(w, r, challenge.Username) {
+		return
+	}
+	auditLogin(r, challenge.Username, nil)
+	recordSuccess(challenge.Username)
+
+	responseByte, err := json.Marshal(response)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to json marshal response: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(responseByte)
+}
@@ -1,14 +1,18 @@
 package apiAuth
 
-import "net/http"
+import (
+	"net/http"
+	apiMiddleware "runny-code/api/middleware"
+	"runny-code/sessions"
+)
 
 func LogoutHandle(w http.ResponseWriter, r *http.Request) {
-	http.SetCookie(w, &http.Cookie{
-		Name:   "session",
-		Value:  "",
-		Path:   "/",
-		MaxAge: -1,
-	})
+	cookie, err := r.Cookie(apiMiddleware.SessionCookieName)
+	if err == nil {
+		sessions.DeleteByToken(cookie.Value)
+	}
+
+	apiMiddleware.ClearSessionCookies(w, r)
 
 	w.Write([]byte("Logged out"))
-}
\ No newline at end of file
+}
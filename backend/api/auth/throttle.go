@@ -0,0 +1,41 @@
+package apiAuth
+
+import (
+	"math"
+	"net/http"
+	"runny-code/audit"
+	"runny-code/common"
+	"runny-code/identity"
+	"runny-code/throttle"
+	"strconv"
+)
+
+// checkThrottle responds with too many requests while the IP or the username is locked out
+func checkThrottle(w http.ResponseWriter, r *http.Request, username string) bool {
+	retryAfter := throttle.RetryAfter(throttle.IPKey(common.ClientIP(r)), throttle.UsernameKey(username))
+	if retryAfter <= 0 {
+		return true
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	http.Error(w, "Too many failed logins, try again later", http.StatusTooManyRequests)
+	return false
+}
+
+// recordFailure counts a failed login for the IP and the username, and audits the lockouts it causes
+func recordFailure(r *http.Request, username string) {
+	locked := throttle.Fail(throttle.IPKey(common.ClientIP(r)), throttle.UsernameKey(username))
+	if len(locked) == 0 {
+		return
+	}
+
+	r = r.WithContext(identity.WithIdentity(r.Context(), identity.Identity{Username: username}))
+	for key, lockout := range locked {
+		audit.Record(r, "auth.lockout", key, nil, map[string]string{"lockout": lockout.String()})
+	}
+}
+
+// recordSuccess forgets the failures of the username, the IP keeps its count until it expires
+func recordSuccess(username string) {
+	throttle.Succeed(throttle.UsernameKey(username))
+}
@@ -0,0 +1,54 @@
+package apiChanges
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runny-code/changes"
+	"time"
+)
+
+const heartbeatInterval = 15 * time.Second
+
+// ChangeEventsHandle streams Server-Sent Events telling the UI to fetch the commands or webhooks again
+// e.g. after their files were edited on disk or by another user
+func ChangeEventsHandle(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	events, unsubscribe := changes.Subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case event := <-events:
+			eventByte, err := json.Marshal(event)
+			if err != nil {
+				return
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, eventByte); err != nil {
+				return
+			}
+			ticker.Reset(heartbeatInterval)
+		}
+		flusher.Flush()
+	}
+}
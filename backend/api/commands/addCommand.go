@@ -3,12 +3,15 @@ package apiCommands
 import (
 	"fmt"
 	"net/http"
+	"runny-code/audit"
 	"runny-code/commands"
-	"runny-code/common"
+	"runny-code/identity"
+	"runny-code/roles"
+	"time"
 )
 
 func AddCommandHandle(w http.ResponseWriter, r *http.Request) {
-	if common.Allow_Command_Manipulation_Env != "true" {
+	if !roles.CanManageCommands(identity.FromRequest(r).Role) {
 		http.Error(w, "Unauthorized: Command manipulation is disabled", http.StatusUnauthorized)
 		return
 	}
@@ -16,6 +19,10 @@ func AddCommandHandle(w http.ResponseWriter, r *http.Request) {
 	commandName := r.FormValue("commandName")
 	groupName := r.FormValue("groupName")
 	description := r.FormValue("description")
+	executor := r.FormValue("executor")
+	hostNames := r.FormValue("hosts")
+	timeout := r.FormValue("timeout")
+	tty := r.FormValue("tty") == "true"
 	command := r.FormValue("command")
 
 	if commandName == "" {
@@ -28,7 +35,17 @@ func AddCommandHandle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	foundCommand := commands.FindCommand(commands.ParsedCommandsList, commandName)
+	if executor != "" && !commands.IsValidExecutor(executor) {
+		http.Error(w, fmt.Sprintf("Unknown executor '%s'", executor), http.StatusBadRequest)
+		return
+	}
+
+	if _, err := time.ParseDuration(timeout); timeout != "" && err != nil {
+		http.Error(w, fmt.Sprintf("Invalid timeout '%s'", timeout), http.StatusBadRequest)
+		return
+	}
+
+	foundCommand := commands.FindCommand(commands.List(), commandName)
 	if foundCommand != nil {
 		http.Error(w, fmt.Sprintf("Command '%s' already exists", commandName), http.StatusBadRequest)
 		return
@@ -38,21 +55,30 @@ func AddCommandHandle(w http.ResponseWriter, r *http.Request) {
 		CommandName: commandName,
 		GroupName:   groupName,
 		Description: description,
+		Executor:    executor,
+		Hosts:       hostNames,
+		Timeout:     timeout,
+		TTY:         tty,
 		Command:     command,
 	}
 
-	err := commands.AddCommandToFile(common.CommandsFile, addCommandInput)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
+	// add the command and update the commands list, checked again as another request may have added it meanwhile
+	err := commands.Update(func(current []commands.ParsedCommand) error {
+		if commands.FindCommand(current, commandName) != nil {
+			return fmt.Errorf("command '%s' already exists", commandName)
+		}
 
-	// update the commands list after adding the new command
-	commandsList, err := commands.ParseCommands(common.CommandsFile)
+		targetFile, err := commands.AddTargetFile()
+		if err != nil {
+			return err
+		}
+		return commands.AddCommandToFile(targetFile, addCommandInput)
+	})
+	audit.Record(r, "command.add", commandName, err, nil)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
 	}
-	commands.ParsedCommandsList = commandsList
 
 	w.Write([]byte("Command added successfully"))
 }
@@ -4,14 +4,28 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	apiPermissions "runny-code/api/permissions"
 	"runny-code/commands"
+	"runny-code/identity"
+	"slices"
+	"strconv"
 )
 
+// GetCommandsListHandle returns the commands the caller and its API token can execute, with their version in the `X-Commands-Version` header
 func GetCommandsListHandle(w http.ResponseWriter, r *http.Request) {
-	commandsListByte, err := json.Marshal(commands.ParsedCommandsList)
+	commandsList, version := commands.Snapshot()
+
+	caller := identity.FromRequest(r)
+	commandsList = slices.DeleteFunc(slices.Clone(commandsList), func(command commands.ParsedCommand) bool {
+		return !apiPermissions.CanExecute(caller, command.Group, command.Name)
+	})
+
+	commandsListByte, err := json.Marshal(commandsList)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to json marshal commands list: %s", err.Error()), http.StatusInternalServerError)
+		return
 	}
 
+	w.Header().Set("X-Commands-Version", strconv.FormatUint(version, 10))
 	w.Write(commandsListByte)
 }
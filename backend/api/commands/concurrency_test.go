@@ -0,0 +1,119 @@
+package apiCommands
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	apiWebhooks "runny-code/api/webhooks"
+	"runny-code/commands"
+	"runny-code/common"
+	"runny-code/identity"
+	"runny-code/jobs"
+	"runny-code/users"
+	"runny-code/webhooks"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// useConfig runs the test from a directory whose config has the commands file, with the local executor and command manipulation allowed
+func useConfig(t *testing.T, commandsFile string) {
+	root := t.TempDir()
+	for _, dir := range []string{"config", "backend"} {
+		if err := os.Mkdir(filepath.Join(root, dir), 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(filepath.Join(root, "backend"))
+
+	previousManipulation, previousExecutor, previousShell := common.Allow_Command_Manipulation_Env, common.Executor_Env, common.Local_Shell_Env
+	common.Allow_Command_Manipulation_Env, common.Executor_Env, common.Local_Shell_Env = "true", commands.ExecutorLocal, "/bin/sh"
+	t.Cleanup(func() {
+		common.Allow_Command_Manipulation_Env, common.Executor_Env, common.Local_Shell_Env = previousManipulation, previousExecutor, previousShell
+	})
+
+	if err := os.WriteFile(common.CommandsFile, []byte(commandsFile), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, setup := range []func() error{commands.Reload, webhooks.CreateFile, webhooks.Load, jobs.CreateFile} {
+		if err := setup(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// serve calls the handler as an admin and fails the test unless it responds with 200
+func serve(t *testing.T, handler http.HandlerFunc, r *http.Request) string {
+	r = r.WithContext(identity.WithIdentity(r.Context(), identity.Identity{Username: "admin", Role: users.RoleAdmin}))
+	w := httptest.NewRecorder()
+	handler(w, r)
+	if w.Code != http.StatusOK {
+		t.Errorf("%s %s: got status %d: %s", r.Method, r.URL, w.Code, w.Body)
+	}
+	return w.Body.String()
+}
+
+func TestConcurrentCommandChanges(t *testing.T) {
+	useConfig(t, "@name Echo\necho hello\n")
+
+	query := url.Values{"commandName": {"Echo"}, "command": {"echo hello"}}
+	serve(t, apiWebhooks.CreateForCommand, httptest.NewRequest("PUT", "/create-webhook/?"+query.Encode(), nil))
+	webhookUUID := webhooks.List()[0].UUID
+
+	const workers, rounds = 4, 5
+	wait := sync.WaitGroup{}
+	for worker := range workers {
+		wait.Add(3)
+
+		// adds a command with a webhook then deletes both
+		go func() {
+			defer wait.Done()
+			for round := range rounds {
+				name := fmt.Sprintf("Temp %d %d", worker, round)
+				form := url.Values{"commandName": {name}, "command": {"echo " + name}}
+				request := httptest.NewRequest("PUT", "/command/", strings.NewReader(form.Encode()))
+				request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+				serve(t, AddCommandHandle, request)
+
+				serve(t, apiWebhooks.CreateForCommand, httptest.NewRequest("PUT", "/create-webhook/?"+form.Encode(), nil))
+				serve(t, DeleteCommandHandle, httptest.NewRequest("DELETE", "/command/?"+form.Encode(), nil))
+			}
+		}()
+
+		go func() {
+			defer wait.Done()
+			for range rounds {
+				request := httptest.NewRequest("POST", "/command/?name=Echo&command=echo+hello", strings.NewReader("{}"))
+				if output := serve(t, ExecuteCommandHandle, request); output != "hello\n" {
+					t.Errorf("executed with output %q", output)
+				}
+			}
+		}()
+
+		go func() {
+			defer wait.Done()
+			for range rounds {
+				request := httptest.NewRequest("GET", "/webhook/"+webhookUUID+"/", nil)
+				request.SetPathValue("uuid", webhookUUID)
+				if output := serve(t, apiWebhooks.HandleMessages, request); output != "hello\n" {
+					t.Errorf("webhook executed with output %q", output)
+				}
+			}
+		}()
+	}
+	wait.Wait()
+
+	parsed, err := commands.ParseCommands(common.CommandsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed) != 1 || len(commands.List()) != 1 {
+		t.Errorf("got %d commands in the file and %d loaded, want only Echo", len(parsed), len(commands.List()))
+	}
+	if len(webhooks.List()) != 1 {
+		t.Errorf("got webhooks %+v, want only the one of Echo", webhooks.List())
+	}
+}
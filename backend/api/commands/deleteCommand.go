@@ -3,13 +3,15 @@ package apiCommands
 import (
 	"fmt"
 	"net/http"
+	"runny-code/audit"
 	"runny-code/commands"
-	"runny-code/common"
+	"runny-code/identity"
+	"runny-code/roles"
 	"runny-code/webhooks"
 )
 
 func DeleteCommandHandle(w http.ResponseWriter, r *http.Request) {
-	if common.Allow_Command_Manipulation_Env != "true" {
+	if !roles.CanManageCommands(identity.FromRequest(r).Role) {
 		http.Error(w, "Unauthorized: Command manipulation is disabled", http.StatusUnauthorized)
 		return
 	}
@@ -26,28 +28,30 @@ func DeleteCommandHandle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	foundCommand := commands.FindCommand(commands.ParsedCommandsList, commandName)
+	foundCommand := commands.FindCommand(commands.List(), commandName)
 	if foundCommand == nil {
 		http.Error(w, fmt.Sprintf("Command '%s' does not exists", commandName), http.StatusBadRequest)
 		return
 	}
 
-	err := commands.RemoveCommandFromFile(common.CommandsFile, *foundCommand)
+	// remove the command and update the commands list, found again as another request may have removed it meanwhile
+	err := commands.Update(func(current []commands.ParsedCommand) error {
+		foundCommand := commands.FindCommand(current, commandName)
+		if foundCommand == nil {
+			return fmt.Errorf("command '%s' does not exists", commandName)
+		}
+		return commands.RemoveCommandFromFile(foundCommand.File, *foundCommand)
+	})
+	audit.Record(r, "command.delete", commandName, err, nil)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	// update the commands list after adding the new command
-	commandsList, err := commands.ParseCommands(common.CommandsFile)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-	}
-	commands.ParsedCommandsList = commandsList
-
 	// delete the webhook if it exists (ignores errors)
 	if keepWebhook != "true" {
-		webhooks.DeleteEntry(commandName, command)
+		err = webhooks.DeleteEntry(commandName, command)
+		audit.Record(r, "webhook.delete", commandName, err, nil)
 	}
 
 	w.Write([]byte("Command removed successfully"))
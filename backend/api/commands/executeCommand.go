@@ -2,11 +2,20 @@ package apiCommands
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"regexp"
+	apiPermissions "runny-code/api/permissions"
 	"runny-code/commands"
+	"runny-code/common"
+	"runny-code/identity"
+	"runny-code/jobs"
+	"sync"
 )
 
+var rmLnRe = regexp.MustCompile(`.*?\r`)
+
 type ExecuteCommandResponse struct {
 	Stdout string `json:"stdout"`
 	Stderr string `json:"stderr"`
@@ -26,21 +35,19 @@ func ExecuteCommandHandle(w http.ResponseWriter, r *http.Request) {
 	}
 
 	streamOutput := r.URL.Query().Get("streamOutput") == "true"
+	hostName := r.URL.Query().Get("host")
 
 	// Find the command
-	var parsedCommand *commands.ParsedCommand
-	for _, cmd := range commands.ParsedCommandsList {
-		if cmd.Name == commandName && cmd.Command == commandStr {
-			parsedCommand = &cmd
-			break
-		}
-	}
+	parsedCommand := commands.Lookup(commandName, commandStr)
 
 	// Not found
 	if parsedCommand == nil {
 		http.Error(w, fmt.Sprintf("Command '%s' not found", commandName), http.StatusNotFound)
 		return
 	}
+	if !apiPermissions.AllowCommand(w, r, parsedCommand.Group, parsedCommand.Name) {
+		return
+	}
 
 	// Get command arguments (input)
 	var data map[string]string
@@ -50,19 +57,26 @@ func ExecuteCommandHandle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Prepare the command to execute
-	commandToExecute, err := commands.FillCommand(parsedCommand.Command, parsedCommand.Variables, data)
-	if err != nil {
+	// Prepare the job to execute
+	job, err := jobs.Create(parsedCommand, jobs.Origin{Source: jobs.SourceUI, Actor: identity.FromRequest(r).Username, IP: common.ClientIP(r)}, hostName, data)
+	if errors.Is(err, jobs.ErrSaveFailed) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("X-Job-Id", job.ID)
 
+	// The job is stopped when the client disconnects
 	if !streamOutput {
-		output, err := commands.SShExecute(commandToExecute)
+		err = jobs.Run(r.Context(), job, nil)
+		result, _ := jobs.Get(job.ID)
 		if err != nil {
-			http.Error(w, fmt.Sprintf("Failed to execute command '%s': %s", commandToExecute, err.Error()), http.StatusInternalServerError)
+			http.Error(w, fmt.Sprintf("Failed to execute command '%s': %s", commandName, err.Error()), http.StatusInternalServerError)
 		}
-		w.Write(output)
+		w.Write([]byte(result.Output))
 		return
 	}
 
@@ -77,13 +91,30 @@ func ExecuteCommandHandle(w http.ResponseWriter, r *http.Request) {
 	// Use http.Flusher to stream output
 	flusher, ok := w.(http.Flusher)
 	if !ok {
+		jobs.Cancel(job.ID)
 		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
 		return
 	}
 
 	// Execute the command and stream output
-	err = commands.SShExecuteStream(commandToExecute, func(stdout string, stderr string) {
-		w.Write([]byte(stdout + stderr) )
+	// Sends the entire accumulated output of the updated stream on each chunk, use `/job/{id}/events` for incremental output
+	var outputMutex sync.Mutex
+	currentStdout, currentStderr := "", ""
+	err = jobs.Run(r.Context(), job, func(stdout string, stderr string) {
+		outputMutex.Lock()
+		defer outputMutex.Unlock()
+
+		output := ""
+		if stdout != "" {
+			currentStdout = rmLnRe.ReplaceAllString(currentStdout+stdout, "")
+			output = currentStdout
+		}
+		if stderr != "" {
+			currentStderr = rmLnRe.ReplaceAllString(currentStderr+stderr, "")
+			output = currentStderr
+		}
+
+		w.Write([]byte(output))
 		flusher.Flush()
 	})
 
@@ -2,11 +2,12 @@ package apiCommands
 
 import (
 	"net/http"
-	"runny-code/common"
+	"runny-code/identity"
+	"runny-code/roles"
 )
 
 func IsManipulationAllowedHandle(w http.ResponseWriter, r *http.Request) {
-	if common.Allow_Command_Manipulation_Env == "true" {
+	if roles.CanManageCommands(identity.FromRequest(r).Role) {
 		w.Write([]byte("true"))
 	} else {
 		w.Write([]byte("false"))
@@ -0,0 +1,41 @@
+package apiCommands
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runny-code/commands"
+)
+
+type lintResult struct {
+	Diagnostics []commands.Diagnostic `json:"diagnostics"`
+	Errors      int                   `json:"errors"`
+	Warnings    int                   `json:"warnings"`
+}
+
+// LintCommandsHandle checks the commands files and returns the errors and warnings with their positions
+func LintCommandsHandle(w http.ResponseWriter, r *http.Request) {
+	files, err := commands.CommandsFiles()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to find commands files: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	result := lintResult{Diagnostics: commands.Lint(files)}
+	for _, diagnostic := range result.Diagnostics {
+		if diagnostic.Severity == commands.SeverityError {
+			result.Errors++
+		} else {
+			result.Warnings++
+		}
+	}
+
+	resultByte, err := json.Marshal(result)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to json marshal diagnostics: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(resultByte)
+}
@@ -0,0 +1,159 @@
+package apiCommands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	apiPermissions "runny-code/api/permissions"
+	"runny-code/commands"
+	"runny-code/common"
+	"runny-code/identity"
+	"runny-code/jobs"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+// terminalMessage is sent by the client over the terminal WebSocket
+// The first message must be `start`, then `stdin` and `resize` in any order
+type terminalMessage struct {
+	Type string            `json:"type"` // start | stdin | resize
+	Data string            `json:"data"`
+	Args map[string]string `json:"args"`
+	Cols int               `json:"cols"`
+	Rows int               `json:"rows"`
+}
+
+var terminalUpgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool {
+		// same-origin only, except in development where the UI is served separately
+		if common.App_ENV == "development" {
+			return true
+		}
+		origin := r.Header.Get("Origin")
+		return origin == "" || origin == "http://"+r.Host || origin == "https://"+r.Host
+	},
+}
+
+// TerminalHandle runs a `@tty` command in a pseudo terminal over a WebSocket
+// Terminal output is sent as binary messages, job info and the exit status as JSON text messages
+func TerminalHandle(w http.ResponseWriter, r *http.Request) {
+	commandName := r.URL.Query().Get("name")
+	if commandName == "" {
+		http.Error(w, "Missing command name parameter", http.StatusBadRequest)
+		return
+	}
+
+	commandStr := r.URL.Query().Get("command")
+	if commandStr == "" {
+		http.Error(w, "Missing command parameter", http.StatusBadRequest)
+		return
+	}
+
+	hostName := r.URL.Query().Get("host")
+
+	// Find the command
+	parsedCommand := commands.Lookup(commandName, commandStr)
+	if parsedCommand == nil {
+		http.Error(w, fmt.Sprintf("Command '%s' not found", commandName), http.StatusNotFound)
+		return
+	}
+	if !apiPermissions.AllowCommand(w, r, parsedCommand.Group, parsedCommand.Name) {
+		return
+	}
+	if !parsedCommand.TTY {
+		http.Error(w, fmt.Sprintf("Command '%s' is not a @tty command", commandName), http.StatusBadRequest)
+		return
+	}
+
+	conn, err := terminalUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return // the upgrader already replied
+	}
+	defer conn.Close()
+
+	// gorilla/websocket supports one concurrent writer
+	var writeMutex sync.Mutex
+	writeJSON := func(v any) error {
+		writeMutex.Lock()
+		defer writeMutex.Unlock()
+		return conn.WriteJSON(v)
+	}
+
+	var start terminalMessage
+	if err := conn.ReadJSON(&start); err != nil || start.Type != "start" {
+		writeJSON(jobs.Event{Type: jobs.EventError, Data: "the first message must be of type 'start'"})
+		return
+	}
+
+	job, err := jobs.Create(parsedCommand, jobs.Origin{Source: jobs.SourceUI, Actor: identity.FromRequest(r).Username, IP: common.ClientIP(r)}, hostName, start.Args)
+	if err != nil {
+		writeJSON(jobs.Event{Type: jobs.EventError, Data: err.Error()})
+		return
+	}
+	writeJSON(map[string]string{"type": "job", "id": job.ID})
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	stdinReader, stdinWriter := io.Pipe()
+	defer stdinWriter.Close()
+	resize := make(chan commands.WindowSize, 8)
+
+	// Forward client messages until the client disconnects, which stops the job
+	go func() {
+		defer cancel()
+		defer stdinWriter.Close()
+		defer close(resize)
+
+		for {
+			var message terminalMessage
+			if err := conn.ReadJSON(&message); err != nil {
+				return
+			}
+
+			switch message.Type {
+			case "stdin":
+				stdinWriter.Write([]byte(message.Data))
+			case "resize":
+				select {
+				case resize <- commands.WindowSize{Cols: message.Cols, Rows: message.Rows}:
+				default: // drop if the terminal is not keeping up, the next resize wins
+				}
+			}
+		}
+	}()
+
+	terminal := commands.Terminal{
+		Stdin:  stdinReader,
+		Size:   commands.WindowSize{Cols: start.Cols, Rows: start.Rows},
+		Resize: resize,
+		Output: terminalOutput(func(p []byte) error {
+			writeMutex.Lock()
+			defer writeMutex.Unlock()
+			return conn.WriteMessage(websocket.BinaryMessage, p)
+		}),
+	}
+
+	err = jobs.RunInteractive(ctx, job, terminal)
+
+	result, _ := jobs.Get(job.ID)
+	if err != nil && result.ExitCode == nil {
+		writeJSON(jobs.Event{Type: jobs.EventError, Data: err.Error()})
+	}
+	writeJSON(jobs.Event{Type: jobs.EventExit, ExitCode: result.ExitCode, State: result.State})
+
+	writeMutex.Lock()
+	conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	writeMutex.Unlock()
+}
+
+type terminalOutput func(p []byte) error
+
+func (f terminalOutput) Write(p []byte) (int, error) {
+	if err := f(p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
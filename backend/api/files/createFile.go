@@ -1,8 +1,10 @@
 package apiFiles
 
 import (
+	"cmp"
 	"net/http"
 	"path"
+	"runny-code/audit"
 	"runny-code/common"
 )
 
@@ -14,8 +16,13 @@ func CreateFileHandle(w http.ResponseWriter, r *http.Request) {
 	}
 
 	filePath := path.Join(common.FilesDir, whereToCreate)
+	if !checkWritable(w, r, filePath) {
+		return
+	}
 
 	createdPath, err := createFile(filePath)
+	// the directory when the file could not be created
+	audit.Record(r, "file.create", relativePath(cmp.Or(createdPath, filePath)), err, nil)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -1,8 +1,10 @@
 package apiFiles
 
 import (
+	"cmp"
 	"net/http"
 	"path"
+	"runny-code/audit"
 	"runny-code/common"
 )
 
@@ -12,8 +14,15 @@ func CreateFolderHandle(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Missing 'whereToCreate' parameter", http.StatusBadRequest)
 		return
 	}
-	
-	createdPath, err := createFolder(path.Join(common.FilesDir, whereToCreate))
+
+	dirPath := path.Join(common.FilesDir, whereToCreate)
+	if !checkWritable(w, r, dirPath) {
+		return
+	}
+
+	createdPath, err := createFolder(dirPath)
+	// the directory when the folder could not be created
+	audit.Record(r, "folder.create", relativePath(cmp.Or(createdPath, dirPath)), err, nil)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
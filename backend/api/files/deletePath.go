@@ -3,6 +3,7 @@ package apiFiles
 import (
 	"net/http"
 	"path"
+	"runny-code/audit"
 	"runny-code/common"
 )
 
@@ -13,7 +14,13 @@ func DeletePathHandle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	
-	err := deletePath(path.Join(common.FilesDir, pathToDelete))
+	fullPath := path.Join(common.FilesDir, pathToDelete)
+	if !checkWritable(w, r, fullPath) {
+		return
+	}
+
+	err := deletePath(fullPath)
+	audit.Record(r, "file.delete", relativePath(fullPath), err, nil)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -9,6 +9,8 @@ import (
 	"path"
 	"path/filepath"
 	"runny-code/common"
+	"runny-code/identity"
+	"runny-code/roles"
 )
 
 func DownloadHandle(w http.ResponseWriter, r *http.Request) {
@@ -19,6 +21,9 @@ func DownloadHandle(w http.ResponseWriter, r *http.Request) {
 	}
 
 	fullPath := path.Join(common.FilesDir, pathToDownload)
+	if !checkReadable(w, r, fullPath) {
+		return
+	}
 
 	// Check if the path exists and get its info
 	fileInfo, err := os.Stat(fullPath)
@@ -32,7 +37,7 @@ func DownloadHandle(w http.ResponseWriter, r *http.Request) {
 
 	// directory
 	if fileInfo.IsDir() {
-		zipFilePath, err := zipDirectory(fullPath)
+		zipFilePath, err := zipDirectory(fullPath, identity.FromRequest(r).Role)
 		if err != nil {
 			http.Error(w, fmt.Sprintf("Error zipping directory: %v", err), http.StatusInternalServerError)
 			return
@@ -47,7 +52,8 @@ func DownloadHandle(w http.ResponseWriter, r *http.Request) {
 	sendFile(w, r, fullPath, fileInfo.Name())
 }
 
-func zipDirectory(sourceDir string) (string, error) {
+// zipDirectory zips the directory without the entries hidden from the role
+func zipDirectory(sourceDir string, role string) (string, error) {
 	zipFilePath := path.Join(os.TempDir(), path.Base(sourceDir)+".zip")
 	zipFile, err := os.Create(zipFilePath)
 	if err != nil {
@@ -64,6 +70,13 @@ func zipDirectory(sourceDir string) (string, error) {
 			return fmt.Errorf("error walking file path %s: %w", filePath, err)
 		}
 
+		if roles.IsHidden(role, relativePath(filePath)) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
 		// Get the relative path for the zip entry
 		relPath, err := filepath.Rel(sourceDir, filePath)
 		if err != nil {
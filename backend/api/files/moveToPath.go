@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"path"
+	"runny-code/audit"
 	"runny-code/common"
 )
 
@@ -21,8 +22,12 @@ func MoveToPathHandle(w http.ResponseWriter, r *http.Request) {
 
 	fromPath = path.Join(common.FilesDir, fromPath)
 	toPath = path.Join(common.FilesDir, toPath)
+	if !checkWritable(w, r, fromPath) || !checkWritable(w, r, toPath) {
+		return
+	}
 
 	err := moveToPath(fromPath, toPath)
+	audit.Record(r, "file.move", relativePath(fromPath), err, map[string]string{"to": relativePath(toPath)})
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
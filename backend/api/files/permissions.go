@@ -0,0 +1,72 @@
+package apiFiles
+
+import (
+	"io/fs"
+	"net/http"
+	"path"
+	"path/filepath"
+	"runny-code/common"
+	"runny-code/identity"
+	"runny-code/roles"
+	"strings"
+)
+
+// relativePath returns the path inside the files directory, as matched by the role rules
+func relativePath(fullPath string) string {
+	relative := strings.TrimPrefix(path.Clean(fullPath), path.Clean(common.FilesDir))
+	return strings.TrimPrefix(relative, "/")
+}
+
+// checkReadable responds with not found when the path is hidden from the user of the request
+func checkReadable(w http.ResponseWriter, r *http.Request, fullPath string) bool {
+	if roles.IsHidden(identity.FromRequest(r).Role, relativePath(fullPath)) {
+		http.Error(w, "File or directory not found", http.StatusNotFound)
+		return false
+	}
+	return true
+}
+
+// checkWritable responds with forbidden when the user of the request can not change the path
+// For directories every entry inside is checked, so a protected subtree can not be moved or deleted with its parent
+func checkWritable(w http.ResponseWriter, r *http.Request, fullPath string) bool {
+	role := identity.FromRequest(r).Role
+	if !roles.HasPathRules(role) {
+		return true
+	}
+
+	if !roles.IsReadOnly(role, relativePath(fullPath)) {
+		protected := false
+		filepath.WalkDir(fullPath, func(entryPath string, entry fs.DirEntry, err error) error {
+			if err == nil && roles.IsReadOnly(role, relativePath(entryPath)) {
+				protected = true
+				return filepath.SkipAll
+			}
+			return nil
+		})
+		if !protected {
+			return true
+		}
+	}
+
+	http.Error(w, "Forbidden: the path is read-only", http.StatusForbidden)
+	return false
+}
+
+// removeHidden drops the entries hidden from the role, must be called before RemoveLeading
+func (f *Folder) removeHidden(role string) {
+	folders := make([]Folder, 0, len(f.Folders))
+	for _, folder := range f.Folders {
+		if !roles.IsHidden(role, relativePath(folder.Path)) {
+			folders = append(folders, folder)
+		}
+	}
+	f.Folders = folders
+
+	files := make([]File, 0, len(f.Files))
+	for _, file := range f.Files {
+		if !roles.IsHidden(role, relativePath(file.Path)) {
+			files = append(files, file)
+		}
+	}
+	f.Files = files
+}
@@ -5,11 +5,15 @@ import (
 	"net/http"
 	"path"
 	"runny-code/common"
+	"runny-code/identity"
 )
 
 func ReadDirHandle(w http.ResponseWriter, r *http.Request) {
 	dirPath := r.URL.Query().Get("directory")
 	dirPath = path.Join(common.FilesDir, dirPath)
+	if !checkReadable(w, r, dirPath) {
+		return
+	}
 
 	rootFolder, err := readDir(dirPath)
 	if err != nil {
@@ -17,6 +21,8 @@ func ReadDirHandle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	rootFolder.removeHidden(identity.FromRequest(r).Role)
+
 	// remove the leading `FilesDir` from each path
 	rootFolder.RemoveLeading(common.FilesDir)
 
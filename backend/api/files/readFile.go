@@ -9,6 +9,9 @@ import (
 func ReadFileHandle(w http.ResponseWriter, r *http.Request) {
 	requestedFilePath := r.URL.Query().Get("filePath")
 	requestedFilePath = path.Join(common.FilesDir, requestedFilePath)
+	if !checkReadable(w, r, requestedFilePath) {
+		return
+	}
 
 	// read the file
 	content, err := readTextFile(requestedFilePath)
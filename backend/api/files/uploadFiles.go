@@ -8,7 +8,10 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"runny-code/audit"
 	"runny-code/common"
+	"runny-code/identity"
+	"runny-code/roles"
 )
 
 func UploadHandle(w http.ResponseWriter, r *http.Request) {
@@ -19,6 +22,9 @@ func UploadHandle(w http.ResponseWriter, r *http.Request) {
 	}
 
 	destination = path.Join(common.FilesDir, destination)
+	if !checkWritable(w, r, destination) {
+		return
+	}
 
 	err := r.ParseMultipartForm(0) // 0 means no explicit memory limit
 	if err != nil {
@@ -49,6 +55,11 @@ func UploadHandle(w http.ResponseWriter, r *http.Request) {
 			continue
 		}
 
+		if roles.IsReadOnly(identity.FromRequest(r).Role, relativePath(filePath)) {
+			warnings = append(warnings, fmt.Sprintf("File '%s' is read-only and was skipped", fileHeader.Filename))
+			continue
+		}
+
 		if isExcluded(filePath) {
 			warnings = append(warnings, fmt.Sprintf("File '%s' uploaded but is excluded; you will not be able to access it.", fileHeader.Filename))
 		}
@@ -59,6 +70,7 @@ func UploadHandle(w http.ResponseWriter, r *http.Request) {
 
 		dst, err := os.Create(filePath)
 		if err != nil {
+			audit.Record(r, "file.upload", relativePath(filePath), err, nil)
 			http.Error(w, fmt.Sprintf("Error creating file %s: %v", fileHeader.Filename, err), http.StatusInternalServerError)
 			return
 		}
@@ -66,6 +78,7 @@ func UploadHandle(w http.ResponseWriter, r *http.Request) {
 
 		// Stream the content from the uploaded file to the destination file
 		_, err = io.Copy(dst, file)
+		audit.Record(r, "file.upload", relativePath(filePath), err, nil)
 		if err != nil {
 			http.Error(w, fmt.Sprintf("Error saving file %s: %v", fileHeader.Filename, err), http.StatusInternalServerError)
 			return
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"os"
 	"path"
+	"runny-code/audit"
 	"runny-code/common"
 )
 
@@ -17,6 +18,9 @@ func WriteFileHandle(w http.ResponseWriter, r *http.Request) {
 	}
 
 	filePathToSave = path.Join(common.FilesDir, filePathToSave)
+	if !checkWritable(w, r, filePathToSave) {
+		return
+	}
 
 	newContent, err := io.ReadAll(r.Body)
 	if err != nil {
@@ -33,6 +37,7 @@ func WriteFileHandle(w http.ResponseWriter, r *http.Request) {
 	defer file.Close()
 
 	_, err = file.Write(newContent)
+	audit.Record(r, "file.write", relativePath(filePathToSave), err, nil)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to write to the file '%s': %s", filePathToSave, err.Error()), http.StatusInternalServerError)
 		return
@@ -0,0 +1,24 @@
+package apiHosts
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runny-code/hosts"
+)
+
+func GetHostsListHandle(w http.ResponseWriter, r *http.Request) {
+	hostsInfo := []hosts.HostInfo{}
+	for _, host := range hosts.HostEntries {
+		hostsInfo = append(hostsInfo, host.Info())
+	}
+
+	hostsListByte, err := json.Marshal(hostsInfo)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to json marshal hosts list: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(hostsListByte)
+}
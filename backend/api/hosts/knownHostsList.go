@@ -0,0 +1,25 @@
+package apiHosts
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runny-code/hosts"
+)
+
+func GetKnownHostsListHandle(w http.ResponseWriter, r *http.Request) {
+	knownHostsList, err := hosts.ReadKnownHosts()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to read known hosts: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	knownHostsListByte, err := json.Marshal(knownHostsList)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to json marshal known hosts list: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(knownHostsListByte)
+}
@@ -0,0 +1,36 @@
+package apiHosts
+
+import (
+	"fmt"
+	"net/http"
+	"runny-code/audit"
+	"runny-code/commands"
+	"runny-code/hosts"
+	"strconv"
+)
+
+func RevokeKnownHostHandle(w http.ResponseWriter, r *http.Request) {
+	fingerprint := r.URL.Query().Get("fingerprint")
+	host := r.URL.Query().Get("host")
+	if fingerprint == "" {
+		http.Error(w, "Missing fingerprint parameter", http.StatusBadRequest)
+		return
+	}
+
+	removed, err := hosts.RevokeKnownHost(fingerprint, host)
+	audit.Record(r, "knownhost.revoke", fingerprint, err, map[string]string{"host": host, "removed": strconv.Itoa(removed)})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if removed == 0 {
+		http.Error(w, fmt.Sprintf("Fingerprint '%s' not found", fingerprint), http.StatusNotFound)
+		return
+	}
+
+	// drop connections that were verified with the revoked key
+	commands.ClosePooledConnections()
+
+	w.Write(fmt.Appendf(nil, "Revoked %d known host key(s)", removed))
+}
@@ -1,12 +1,22 @@
 package api
 
 import (
+	"crypto/tls"
 	"net/http"
+	apiAudit "runny-code/api/audit"
 	apiAuth "runny-code/api/auth"
+	apiChanges "runny-code/api/changes"
 	apiCommands "runny-code/api/commands"
 	apiFiles "runny-code/api/files"
+	apiHosts "runny-code/api/hosts"
+	apiJobs "runny-code/api/jobs"
 	apiMiddleware "runny-code/api/middleware"
+	apiSessions "runny-code/api/sessions"
+	apiSettings "runny-code/api/settings"
+	apiTokens "runny-code/api/tokens"
+	apiUsers "runny-code/api/users"
 	apiWebhooks "runny-code/api/webhooks"
+	"runny-code/certs"
 	"runny-code/common"
 )
 
@@ -18,9 +28,37 @@ func InitServer() error {
 	mux.Handle("/", fs)
 
 	mux.HandleFunc("POST /login", apiAuth.LoginHandle)
+	mux.HandleFunc("POST /login/2fa", apiAuth.LoginTwoFactorHandle)
+	mux.HandleFunc("GET /login/methods", apiAuth.LoginMethodsHandle)
+	mux.HandleFunc("GET /login/oidc", apiAuth.LoginOIDCHandle)
+	mux.HandleFunc("GET /login/oidc/callback", apiAuth.LoginOIDCCallbackHandle)
 	mux.HandleFunc("GET /logout", apiAuth.LogoutHandle)
 	mux.HandleFunc("GET /is-authenticated", apiAuth.IsAuthenticatedHandle)
 
+	mux.HandleFunc("GET /sessions", apiSessions.GetSessionsListHandle)
+	mux.HandleFunc("DELETE /sessions", apiSessions.RevokeOtherSessionsHandle)
+	mux.HandleFunc("DELETE /session/{id}", apiSessions.RevokeSessionHandle)
+
+	mux.HandleFunc("GET /tokens", apiTokens.GetTokensListHandle)
+	mux.HandleFunc("POST /tokens", apiTokens.CreateTokenHandle)
+	mux.HandleFunc("DELETE /token/{id}", apiTokens.RevokeTokenHandle)
+
+	mux.HandleFunc("GET /current-user", apiUsers.CurrentUserHandle)
+	mux.HandleFunc("GET /users", apiUsers.GetUsersListHandle)
+	mux.HandleFunc("PUT /user/", apiUsers.CreateUserHandle)
+	mux.HandleFunc("POST /user/{username}/disable", apiUsers.DisableUserHandle)
+	mux.HandleFunc("POST /user/{username}/enable", apiUsers.EnableUserHandle)
+	mux.HandleFunc("PUT /user/{username}/password", apiUsers.ChangePasswordHandle)
+	mux.HandleFunc("DELETE /user/{username}/2fa", apiUsers.ResetTwoFactorHandle)
+
+	mux.HandleFunc("POST /2fa/enroll", apiUsers.EnrollTwoFactorHandle)
+	mux.HandleFunc("POST /2fa/confirm", apiUsers.ConfirmTwoFactorHandle)
+	mux.HandleFunc("POST /2fa/recovery-codes", apiUsers.RegenerateRecoveryCodesHandle)
+	mux.HandleFunc("POST /2fa/disable", apiUsers.DisableTwoFactorHandle)
+
+	mux.HandleFunc("GET /settings", apiSettings.GetSettingsHandle)
+	mux.HandleFunc("PUT /settings", apiSettings.UpdateSettingsHandle)
+
 	mux.HandleFunc("GET /read-dir/", apiFiles.ReadDirHandle)
 	mux.HandleFunc("GET /file/", apiFiles.ReadFileHandle)
 	mux.HandleFunc("PUT /file/", apiFiles.WriteFileHandle)
@@ -35,8 +73,24 @@ func InitServer() error {
 	mux.HandleFunc("POST /command/", apiCommands.ExecuteCommandHandle)
 	mux.HandleFunc("PUT /command/", apiCommands.AddCommandHandle)
 	mux.HandleFunc("DELETE /command/", apiCommands.DeleteCommandHandle)
+	mux.HandleFunc("GET /terminal/", apiCommands.TerminalHandle)
+	mux.HandleFunc("GET /lint-commands", apiCommands.LintCommandsHandle)
+	mux.HandleFunc("GET /changes", apiChanges.ChangeEventsHandle)
 	mux.HandleFunc("GET /is-command-manipulation-allowed", apiCommands.IsManipulationAllowedHandle)
 
+	mux.HandleFunc("GET /jobs", apiJobs.GetJobsListHandle)
+	mux.HandleFunc("POST /jobs", apiJobs.StartJobHandle)
+	mux.HandleFunc("GET /job/{id}/events", apiJobs.JobEventsHandle)
+	mux.HandleFunc("GET /job/{id}", apiJobs.GetJobHandle)
+	mux.HandleFunc("DELETE /job/{id}", apiJobs.DeleteJobHandle)
+	mux.HandleFunc("POST /job/{id}/cancel", apiJobs.CancelJobHandle)
+
+	mux.HandleFunc("GET /hosts", apiHosts.GetHostsListHandle)
+	mux.HandleFunc("GET /known-hosts", apiHosts.GetKnownHostsListHandle)
+	mux.HandleFunc("DELETE /known-hosts/", apiHosts.RevokeKnownHostHandle)
+
+	mux.HandleFunc("GET /audit", apiAudit.GetAuditListHandle)
+
 	mux.HandleFunc("PUT /create-webhook/", apiWebhooks.CreateForCommand)
 	mux.HandleFunc("PUT /update-webhook/", apiWebhooks.UpdateForCommand)
 	mux.HandleFunc("DELETE /delete-webhook/", apiWebhooks.DeleteForCommand)
@@ -49,7 +103,12 @@ func InitServer() error {
 		mux.HandleFunc(webhooksRout, apiWebhooks.HandleMessages)
 	}
 
-	err := http.ListenAndServe(":"+common.Port, apiMiddleware.Controller(mux))
+	tlsConfig, err := certs.ServerConfig(common.TLS_Cert_File_Env, common.TLS_Key_File_Env, common.TLS_Client_Auth_Env, common.TLS_Client_CA_File_Env)
+	if err != nil {
+		return err
+	}
+
+	err = listen(":"+common.Port, apiMiddleware.Controller(mux), tlsConfig)
 	if err != nil {
 		return err
 	}
@@ -60,8 +119,24 @@ func InitServer() error {
 func startWebhookServer(route string) {
 	mux := http.NewServeMux()
 	mux.HandleFunc(route, apiWebhooks.HandleMessages)
-	err := http.ListenAndServe(":"+common.Webhook_Port, apiMiddleware.CorsMiddleware(mux))
+
+	tlsConfig, err := certs.ServerConfig(common.Webhook_TLS_Cert_File_Env, common.Webhook_TLS_Key_File_Env, common.Webhook_TLS_Client_Auth_Env, common.Webhook_TLS_Client_CA_File_Env)
+	if err != nil {
+		panic(err)
+	}
+
+	err = listen(":"+common.Webhook_Port, apiMiddleware.WebhookController(mux), tlsConfig)
 	if err != nil {
 		panic(err)
 	}
 }
+
+// listen serves HTTPS when there is a TLS config, plain HTTP otherwise
+func listen(addr string, handler http.Handler, tlsConfig *tls.Config) error {
+	if tlsConfig == nil {
+		return http.ListenAndServe(addr, handler)
+	}
+
+	server := &http.Server{Addr: addr, Handler: handler, TLSConfig: tlsConfig}
+	return server.ListenAndServeTLS("", "")
+}
@@ -0,0 +1,26 @@
+package apiJobs
+
+import (
+	"fmt"
+	"net/http"
+	apiPermissions "runny-code/api/permissions"
+	"runny-code/audit"
+	"runny-code/jobs"
+)
+
+func CancelJobHandle(w http.ResponseWriter, r *http.Request) {
+	jobId := r.PathValue("id")
+
+	job, ok := jobs.Get(jobId)
+	if ok && !apiPermissions.AllowCommand(w, r, job.CommandGroup, job.CommandName) {
+		return
+	}
+
+	if !jobs.Cancel(jobId) {
+		http.Error(w, fmt.Sprintf("Job '%s' is not running", jobId), http.StatusNotFound)
+		return
+	}
+	audit.Record(r, "job.cancel", jobId, nil, nil)
+
+	w.Write([]byte("Job cancelled"))
+}
@@ -0,0 +1,31 @@
+package apiJobs
+
+import (
+	"fmt"
+	"net/http"
+	apiPermissions "runny-code/api/permissions"
+	"runny-code/audit"
+	"runny-code/jobs"
+)
+
+func DeleteJobHandle(w http.ResponseWriter, r *http.Request) {
+	jobId := r.PathValue("id")
+
+	job, ok := jobs.Get(jobId)
+	if !ok {
+		http.Error(w, fmt.Sprintf("Job '%s' not found", jobId), http.StatusNotFound)
+		return
+	}
+	if !apiPermissions.AllowCommand(w, r, job.CommandGroup, job.CommandName) {
+		return
+	}
+
+	err := jobs.Delete(jobId)
+	audit.Record(r, "job.delete", jobId, err, nil)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Write([]byte("Job deleted successfully"))
+}
@@ -0,0 +1,31 @@
+package apiJobs
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	apiPermissions "runny-code/api/permissions"
+	"runny-code/jobs"
+)
+
+func GetJobHandle(w http.ResponseWriter, r *http.Request) {
+	jobId := r.PathValue("id")
+
+	job, ok := jobs.Get(jobId)
+	if !ok {
+		http.Error(w, fmt.Sprintf("Job '%s' not found", jobId), http.StatusNotFound)
+		return
+	}
+	if !apiPermissions.AllowCommand(w, r, job.CommandGroup, job.CommandName) {
+		return
+	}
+
+	jobByte, err := json.Marshal(job)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to json marshal job: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(jobByte)
+}
@@ -0,0 +1,84 @@
+package apiJobs
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	apiPermissions "runny-code/api/permissions"
+	"runny-code/jobs"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const heartbeatInterval = 15 * time.Second
+
+// JobEventsHandle streams the events of a job as Server-Sent Events, or as NDJSON with `?format=ndjson`
+// Clients resume after a reconnect with the `Last-Event-ID` header or the `lastEventId` parameter
+// A `reset` event means the events since that id are gone, the output shown so far must be cleared before the replay
+func JobEventsHandle(w http.ResponseWriter, r *http.Request) {
+	jobId := r.PathValue("id")
+
+	job, ok := jobs.Get(jobId)
+	if !ok {
+		http.Error(w, fmt.Sprintf("Job '%s' not found", jobId), http.StatusNotFound)
+		return
+	}
+	if !apiPermissions.AllowCommand(w, r, job.CommandGroup, job.CommandName) {
+		return
+	}
+
+	lastEventId := r.Header.Get("Last-Event-ID")
+	if lastEventId == "" {
+		lastEventId = r.URL.Query().Get("lastEventId")
+	}
+	lastSeq := 0
+	if lastEventId != "" {
+		seq, err := strconv.Atoi(lastEventId)
+		if err != nil || seq < 0 {
+			http.Error(w, "Invalid last event id", http.StatusBadRequest)
+			return
+		}
+		lastSeq = seq
+	}
+
+	ndjson := r.URL.Query().Get("format") == "ndjson" || strings.Contains(r.Header.Get("Accept"), "application/x-ndjson")
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	if ndjson {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+	} else {
+		w.Header().Set("Content-Type", "text/event-stream")
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	// errors mean the client disconnected, nothing left to report
+	jobs.Subscribe(r.Context(), jobId, lastSeq, heartbeatInterval, func(event jobs.Event) error {
+		eventByte, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+
+		if ndjson {
+			_, err = fmt.Fprintf(w, "%s\n", eventByte)
+		} else if event.Seq == 0 {
+			_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, eventByte)
+		} else {
+			_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.Seq, event.Type, eventByte)
+		}
+		if err != nil {
+			return err
+		}
+
+		flusher.Flush()
+		return nil
+	})
+}
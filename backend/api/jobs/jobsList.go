@@ -0,0 +1,28 @@
+package apiJobs
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	apiPermissions "runny-code/api/permissions"
+	"runny-code/identity"
+	"runny-code/jobs"
+	"slices"
+)
+
+// GetJobsListHandle returns the jobs of the commands the caller can execute, limited to the commands of its API token
+func GetJobsListHandle(w http.ResponseWriter, r *http.Request) {
+	caller := identity.FromRequest(r)
+	jobsList := slices.DeleteFunc(jobs.List(), func(job jobs.Job) bool {
+		return !apiPermissions.CanExecute(caller, job.CommandGroup, job.CommandName)
+	})
+
+	jobsListByte, err := json.Marshal(jobsList)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to json marshal jobs list: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(jobsListByte)
+}
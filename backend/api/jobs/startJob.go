@@ -0,0 +1,73 @@
+package apiJobs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	apiPermissions "runny-code/api/permissions"
+	"runny-code/commands"
+	"runny-code/common"
+	"runny-code/identity"
+	"runny-code/jobs"
+)
+
+// StartJobHandle runs a command in the background and returns the queued job
+// The output is followed with `GET /job/{id}/events`
+func StartJobHandle(w http.ResponseWriter, r *http.Request) {
+	commandName := r.URL.Query().Get("name")
+	if commandName == "" {
+		http.Error(w, "Missing command name parameter", http.StatusBadRequest)
+		return
+	}
+
+	commandStr := r.URL.Query().Get("command")
+	if commandStr == "" {
+		http.Error(w, "Missing command parameter", http.StatusBadRequest)
+		return
+	}
+
+	hostName := r.URL.Query().Get("host")
+
+	// Find the command
+	parsedCommand := commands.Lookup(commandName, commandStr)
+	if parsedCommand == nil {
+		http.Error(w, fmt.Sprintf("Command '%s' not found", commandName), http.StatusNotFound)
+		return
+	}
+	if !apiPermissions.AllowCommand(w, r, parsedCommand.Group, parsedCommand.Name) {
+		return
+	}
+
+	// Get command arguments (input)
+	var data map[string]string
+	err := json.NewDecoder(r.Body).Decode(&data)
+	if err != nil {
+		http.Error(w, "Invalid JSON data", http.StatusBadRequest)
+		return
+	}
+
+	job, err := jobs.Create(parsedCommand, jobs.Origin{Source: jobs.SourceUI, Actor: identity.FromRequest(r).Username, IP: common.ClientIP(r)}, hostName, data)
+	if errors.Is(err, jobs.ErrSaveFailed) {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, _ := jobs.Get(job.ID)
+	jobs.Start(job)
+
+	jobByte, err := json.Marshal(result)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to json marshal job: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Job-Id", job.ID)
+	w.WriteHeader(http.StatusAccepted)
+	w.Write(jobByte)
+}
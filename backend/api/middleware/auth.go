@@ -2,16 +2,24 @@ package apiMiddleware
 
 import (
 	"net/http"
-	"runny-code/common"
+	"runny-code/identity"
+	"runny-code/sessions"
+	"runny-code/tokens"
+	"runny-code/users"
+	"strings"
 )
 
 func authMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		publicRoutes := map[string]bool{
-			"/login":            true,
-			"/logout":           true,
-			"/is-authenticated": true,
-			"/auth/":            true, // web page
+			"/login":               true,
+			"/login/2fa":           true,
+			"/login/methods":       true,
+			"/login/oidc":          true,
+			"/login/oidc/callback": true,
+			"/logout":              true,
+			"/is-authenticated":    true,
+			"/auth/":               true, // web page
 		}
 
 		if publicRoutes[r.URL.Path] {
@@ -28,8 +36,37 @@ func authMiddleware(next http.Handler) http.Handler {
 			}
 		}
 
-		cookie, err := r.Cookie("session")
-		if err != nil || !isValidSession(cookie.Value) {
+		// API tokens are sent as `Authorization: Bearer <token>`
+		if secret, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); found {
+			token, user, valid := tokens.Authenticate(secret)
+			if !valid {
+				http.Error(w, "Unauthorized: Invalid API token", http.StatusUnauthorized)
+				return
+			}
+			if !token.AllowsRoute(r.Method, r.URL.Path) || tokenForbiddenRoutes(r.URL.Path) || !isRouteAllowed(user.Role, r) {
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+
+			r = r.WithContext(identity.WithIdentity(r.Context(), identity.Identity{Username: user.Username, Role: user.Role, Method: identity.MethodToken, TokenID: token.ID, Commands: token.Commands}))
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		session, user, valid := sessionUser(r)
+		if !valid {
+			// machine callers without a session authenticate with a client certificate
+			if certIdentity, found := certificateIdentity(r); found {
+				if tokenForbiddenRoutes(r.URL.Path) || !isRouteAllowed(certIdentity.Role, r) {
+					http.Error(w, "Forbidden", http.StatusForbidden)
+					return
+				}
+
+				r = r.WithContext(identity.WithIdentity(r.Context(), certIdentity))
+				next.ServeHTTP(w, r)
+				return
+			}
+
 			// Redirect to /auth/ only if the request is to /
 			if r.URL.Path == "/" {
 				http.Redirect(w, r, "/auth/", http.StatusFound)
@@ -40,10 +77,30 @@ func authMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
+		if !isRouteAllowed(user.Role, r) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		r = r.WithContext(identity.WithIdentity(r.Context(), identity.Identity{Username: user.Username, Role: user.Role, Method: identity.MethodSession, SessionID: session.ID}))
 		next.ServeHTTP(w, r)
 	})
 }
 
-func isValidSession(token string) bool {
-	return token == common.SessionToken
+// tokenForbiddenRoutes can not be called with an API token or a client certificate, so a leaked one can not create others or change the account
+func tokenForbiddenRoutes(urlPath string) bool {
+	for _, prefix := range []string{"/token", "/user", "/session", "/2fa", "/settings"} {
+		if strings.HasPrefix(urlPath, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func sessionUser(r *http.Request) (sessions.Session, users.User, bool) {
+	cookie, err := r.Cookie(SessionCookieName)
+	if err != nil {
+		return sessions.Session{}, users.User{}, false
+	}
+	return sessions.Authenticate(cookie.Value)
 }
@@ -0,0 +1,37 @@
+package apiMiddleware
+
+import (
+	"net/http"
+	"runny-code/clientcerts"
+	"runny-code/identity"
+)
+
+// clientCertMiddleware sets the identity of a verified client certificate, refuses certificates that are not mapped
+// Requests without one pass, the server asks for certificates with `WEBHOOK_TLS_CLIENT_AUTH`
+func clientCertMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		certIdentity, err := clientcerts.Identify(r.TLS.VerifiedChains[0][0])
+		if err != nil {
+			http.Error(w, "Forbidden: "+err.Error(), http.StatusForbidden)
+			return
+		}
+
+		r = r.WithContext(identity.WithIdentity(r.Context(), certIdentity))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// certificateIdentity maps the verified client certificate of the request, if any
+func certificateIdentity(r *http.Request) (identity.Identity, bool) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 {
+		return identity.Identity{}, false
+	}
+
+	certIdentity, err := clientcerts.Identify(r.TLS.VerifiedChains[0][0])
+	return certIdentity, err == nil
+}
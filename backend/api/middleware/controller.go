@@ -3,5 +3,10 @@ package apiMiddleware
 import "net/http"
 
 func Controller(next http.Handler) http.Handler {
-	return CorsMiddleware(authMiddleware(next))
+	return CorsMiddleware(authMiddleware(csrfMiddleware(next)))
+}
+
+// WebhookController is used by the separate webhook server, which has no sessions
+func WebhookController(next http.Handler) http.Handler {
+	return CorsMiddleware(clientCertMiddleware(next))
 }
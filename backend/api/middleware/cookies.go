@@ -0,0 +1,50 @@
+package apiMiddleware
+
+import (
+	"net/http"
+	"runny-code/common"
+	"runny-code/sessions"
+)
+
+const SessionCookieName = "session"
+const CSRFCookieName = "csrf_token" // readable by scripts, sent back in the `X-CSRF-Token` header
+const CSRFHeaderName = "X-CSRF-Token"
+const OIDCStateCookieName = "oidc_state" // binds a single sign-on to the browser that started it
+
+// SetSessionCookies sets the session cookie and the CSRF cookie derived from it
+func SetSessionCookies(w http.ResponseWriter, r *http.Request, token string) {
+	maxAge := int(sessions.MaxAge().Seconds())
+	http.SetCookie(w, newCookie(r, SessionCookieName, token, maxAge, true))
+	http.SetCookie(w, newCookie(r, CSRFCookieName, sessions.CSRFToken(token), maxAge, false))
+}
+
+func ClearSessionCookies(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, newCookie(r, SessionCookieName, "", -1, true))
+	http.SetCookie(w, newCookie(r, CSRFCookieName, "", -1, false))
+}
+
+// SetOIDCStateCookie is always SameSite=Lax, it must come back with the redirect of the identity provider
+func SetOIDCStateCookie(w http.ResponseWriter, r *http.Request, state string, maxAge int) {
+	cookie := newCookie(r, OIDCStateCookieName, state, maxAge, true)
+	cookie.Path = "/login/oidc"
+	cookie.SameSite = http.SameSiteLaxMode
+	http.SetCookie(w, cookie)
+}
+
+// newCookie is Secure when the request came over TLS, SameSite follows `COOKIE_SAME_SITE`
+func newCookie(r *http.Request, name string, value string, maxAge int, httpOnly bool) *http.Cookie {
+	sameSite := http.SameSiteLaxMode
+	if common.Cookie_Same_Site_Env == "strict" {
+		sameSite = http.SameSiteStrictMode
+	}
+
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   maxAge,
+		HttpOnly: httpOnly,
+		Secure:   common.IsSecureRequest(r),
+		SameSite: sameSite,
+	}
+}
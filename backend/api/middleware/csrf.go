@@ -0,0 +1,68 @@
+package apiMiddleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"runny-code/identity"
+	"runny-code/sessions"
+)
+
+// csrfMiddleware requires the `X-CSRF-Token` header on state changing requests authenticated with the session cookie
+// The token is derived from the session token, so a cross-site page can neither read nor guess it
+// API tokens are not sent by browsers on their own and need no CSRF token
+// Client certificates are, but their callers have no cookie, so cross-site requests are refused by origin instead
+func csrfMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if identity.FromRequest(r).Method == identity.MethodCertificate {
+			if !isSafeMethod(r.Method) && isCrossSite(r) {
+				http.Error(w, "Forbidden: cross-site request", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if identity.FromRequest(r).Method != identity.MethodSession {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		sessionCookie, err := r.Cookie(SessionCookieName)
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		expected := sessions.CSRFToken(sessionCookie.Value)
+
+		// sessions created before the CSRF cookie existed get it on their next request
+		if csrfCookie, err := r.Cookie(CSRFCookieName); err != nil || csrfCookie.Value != expected {
+			SetSessionCookies(w, r, sessionCookie.Value)
+		}
+
+		if isSafeMethod(r.Method) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get(CSRFHeaderName)), []byte(expected)) != 1 {
+			http.Error(w, "Forbidden: missing or invalid CSRF token", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// isCrossSite reports whether a browser sent the request from another origin, other clients send neither header
+func isCrossSite(r *http.Request) bool {
+	if site := r.Header.Get("Sec-Fetch-Site"); site != "" {
+		return site != "same-origin" && site != "none"
+	}
+
+	origin := r.Header.Get("Origin")
+	return origin != "" && origin != "http://"+r.Host && origin != "https://"+r.Host
+}
@@ -0,0 +1,35 @@
+package apiMiddleware
+
+import (
+	"net/http"
+	"runny-code/roles"
+	"runny-code/users"
+)
+
+// Routes limited by role, the handlers check the rules that depend on the command or path
+var adminRoutes = map[string]bool{
+	"GET /audit":           true,
+	"DELETE /known-hosts/": true,
+	"GET /users":           true,
+	"PUT /user/":           true,
+	"GET /settings":        true,
+	"PUT /settings":        true,
+}
+var manageCommandsRoutes = map[string]bool{
+	"PUT /command/":           true,
+	"DELETE /command/":        true,
+	"PUT /create-webhook/":    true,
+	"PUT /update-webhook/":    true,
+	"DELETE /delete-webhook/": true,
+}
+
+func isRouteAllowed(role string, r *http.Request) bool {
+	route := r.Method + " " + r.URL.Path
+	if adminRoutes[route] {
+		return role == users.RoleAdmin
+	}
+	if manageCommandsRoutes[route] {
+		return roles.CanManageCommands(role)
+	}
+	return true
+}
@@ -0,0 +1,33 @@
+package apiPermissions
+
+import (
+	"fmt"
+	"net/http"
+	"runny-code/identity"
+	"runny-code/roles"
+)
+
+// CanExecute reports whether the caller can execute the command, its role must allow the group and its API token the name
+func CanExecute(caller identity.Identity, group string, name string) bool {
+	return roles.CanExecute(caller.Role, group) && caller.AllowsCommand(name)
+}
+
+// AllowCommand writes a forbidden response when the caller of the request can not execute the command
+// Checked before running a command and before showing or changing what belongs to it (jobs, webhooks)
+func AllowCommand(w http.ResponseWriter, r *http.Request, group string, name string) bool {
+	if !roles.CanExecute(identity.FromRequest(r).Role, group) {
+		http.Error(w, fmt.Sprintf("Forbidden: not allowed to execute commands of group '%s'", group), http.StatusForbidden)
+		return false
+	}
+	return AllowCommandName(w, r, name)
+}
+
+// AllowCommandName writes a forbidden response when the API token of the caller does not allow the command
+// Used alone for commands that no longer exist
+func AllowCommandName(w http.ResponseWriter, r *http.Request, name string) bool {
+	if !identity.FromRequest(r).AllowsCommand(name) {
+		http.Error(w, fmt.Sprintf("Forbidden: the API token does not allow command '%s'", name), http.StatusForbidden)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,40 @@
+package apiPermissions
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"runny-code/identity"
+	"runny-code/users"
+	"strings"
+	"testing"
+)
+
+func TestAllowCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		caller   identity.Identity
+		wantCode int
+		wantBody string
+	}{
+		{"operator", identity.Identity{Role: users.RoleOperator}, http.StatusOK, ""},
+		{"viewer can not execute the group", identity.Identity{Role: users.RoleViewer}, http.StatusForbidden, "group 'Deploy'"},
+		{"token allows the command", identity.Identity{Role: users.RoleAdmin, Commands: []string{"Restart"}}, http.StatusOK, ""},
+		{"token allows other commands", identity.Identity{Role: users.RoleAdmin, Commands: []string{"Backup"}}, http.StatusForbidden, "command 'Restart'"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/job/1", nil)
+			r = r.WithContext(identity.WithIdentity(r.Context(), test.caller))
+			w := httptest.NewRecorder()
+
+			allowed := AllowCommand(w, r, "Deploy", "Restart")
+			if allowed != (test.wantCode == http.StatusOK) || w.Code != test.wantCode || !strings.Contains(w.Body.String(), test.wantBody) {
+				t.Errorf("AllowCommand() = %v with %d %q, want status %d containing %q", allowed, w.Code, w.Body, test.wantCode, test.wantBody)
+			}
+			if got := CanExecute(test.caller, "Deploy", "Restart"); got != allowed {
+				t.Errorf("CanExecute() = %v, want %v", got, allowed)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package apiSessions
+
+import (
+	"net/http"
+	"runny-code/audit"
+	"runny-code/identity"
+	"runny-code/sessions"
+	"runny-code/users"
+)
+
+// RevokeSessionHandle signs out a session of the logged in user, admins can revoke any session
+func RevokeSessionHandle(w http.ResponseWriter, r *http.Request) {
+	sessionId := r.PathValue("id")
+	currentUser := identity.FromRequest(r)
+
+	session, found := sessions.Find(sessionId)
+	if !found || (session.Username != currentUser.Username && currentUser.Role != users.RoleAdmin) {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return
+	}
+
+	err := sessions.Delete(sessionId)
+	audit.Record(r, "session.revoke", session.Username, err, map[string]string{"sessionId": sessionId})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write([]byte("Session revoked"))
+}
+
+// RevokeOtherSessionsHandle signs out every session of the logged in user except the current one
+func RevokeOtherSessionsHandle(w http.ResponseWriter, r *http.Request) {
+	currentUser := identity.FromRequest(r)
+
+	err := sessions.DeleteForUser(currentUser.Username, currentUser.SessionID)
+	audit.Record(r, "session.revoke", currentUser.Username, err, map[string]string{"sessionId": "others"})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write([]byte("Other sessions revoked"))
+}
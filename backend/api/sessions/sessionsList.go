@@ -0,0 +1,39 @@
+package apiSessions
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runny-code/identity"
+	"runny-code/sessions"
+	"runny-code/users"
+)
+
+// GetSessionsListHandle returns the sessions of the logged in user
+// Admins can list the sessions of another user with `?username=` or of everyone with `?all=true`
+func GetSessionsListHandle(w http.ResponseWriter, r *http.Request) {
+	currentUser := identity.FromRequest(r)
+
+	username := currentUser.Username
+	requested := r.URL.Query().Get("username")
+	all := r.URL.Query().Get("all") == "true"
+	if (requested != "" && requested != username) || all {
+		if currentUser.Role != users.RoleAdmin {
+			http.Error(w, "Forbidden: admin role required", http.StatusForbidden)
+			return
+		}
+		username = requested
+	}
+	if all {
+		username = ""
+	}
+
+	sessionsListByte, err := json.Marshal(sessions.List(username, currentUser.SessionID))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to json marshal sessions list: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(sessionsListByte)
+}
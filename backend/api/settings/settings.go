@@ -0,0 +1,57 @@
+package apiSettings
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runny-code/audit"
+	"runny-code/settings"
+	"strconv"
+)
+
+func GetSettingsHandle(w http.ResponseWriter, r *http.Request) {
+	writeSettings(w, settings.Current())
+}
+
+// UpdateSettingsHandle changes the settings given as form values, others are kept
+func UpdateSettingsHandle(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		http.Error(w, "Unable to parse form", http.StatusBadRequest)
+		return
+	}
+
+	var require2FA *bool
+	if r.Form.Has("require2FA") {
+		value, err := strconv.ParseBool(r.FormValue("require2FA"))
+		if err != nil {
+			http.Error(w, "Invalid 'require2FA', expected true or false", http.StatusBadRequest)
+			return
+		}
+		require2FA = &value
+	}
+
+	updated, err := settings.Update(func(s *settings.Settings) {
+		if require2FA != nil {
+			s.Require2FA = *require2FA
+		}
+	})
+	audit.Record(r, "settings.update", "settings", err, map[string]string{"require2FA": strconv.FormatBool(updated.Require2FA)})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to save settings: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	writeSettings(w, updated)
+}
+
+func writeSettings(w http.ResponseWriter, current settings.Settings) {
+	settingsByte, err := json.Marshal(current)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to json marshal settings: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(settingsByte)
+}
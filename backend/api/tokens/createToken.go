@@ -0,0 +1,59 @@
+package apiTokens
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runny-code/audit"
+	"runny-code/identity"
+	"runny-code/tokens"
+	"time"
+)
+
+type CreateTokenResponse struct {
+	Token  tokens.TokenInfo `json:"token"`
+	Secret string           `json:"secret"` // only returned once
+}
+
+// CreateTokenHandle creates an API token for the logged in user
+// Form values: name, expiresIn (e.g. 720h, empty never expires), command and route (repeatable, empty for all)
+func CreateTokenHandle(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		http.Error(w, "Unable to parse form", http.StatusBadRequest)
+		return
+	}
+
+	var expiresIn time.Duration
+	if value := r.FormValue("expiresIn"); value != "" {
+		expiresIn, err = time.ParseDuration(value)
+		if err != nil || expiresIn <= 0 {
+			http.Error(w, fmt.Sprintf("Invalid expiresIn '%s', expected a positive duration like 720h", value), http.StatusBadRequest)
+			return
+		}
+	}
+
+	name := r.FormValue("name")
+	tokenInfo, secret, err := tokens.Create(tokens.CreateInput{
+		Name:      name,
+		Username:  identity.FromRequest(r).Username,
+		ExpiresIn: expiresIn,
+		Commands:  r.Form["command"],
+		Routes:    r.Form["route"],
+	})
+	audit.Record(r, "token.create", name, err, map[string]string{"tokenId": tokenInfo.ID})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	responseByte, err := json.Marshal(CreateTokenResponse{Token: tokenInfo, Secret: secret})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to json marshal token: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(responseByte)
+}
@@ -0,0 +1,30 @@
+package apiTokens
+
+import (
+	"net/http"
+	"runny-code/audit"
+	"runny-code/identity"
+	"runny-code/tokens"
+	"runny-code/users"
+)
+
+// RevokeTokenHandle deletes an API token of the logged in user, admins can revoke any token
+func RevokeTokenHandle(w http.ResponseWriter, r *http.Request) {
+	tokenId := r.PathValue("id")
+	currentUser := identity.FromRequest(r)
+
+	token, found := tokens.Find(tokenId)
+	if !found || (token.Username != currentUser.Username && currentUser.Role != users.RoleAdmin) {
+		http.Error(w, "Token not found", http.StatusNotFound)
+		return
+	}
+
+	err := tokens.Delete(tokenId)
+	audit.Record(r, "token.revoke", token.Name, err, map[string]string{"tokenId": tokenId, "username": token.Username})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write([]byte("Token revoked"))
+}
@@ -0,0 +1,33 @@
+package apiTokens
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runny-code/identity"
+	"runny-code/tokens"
+	"runny-code/users"
+)
+
+// GetTokensListHandle returns the API tokens of the logged in user, admins can list everyone's with `?all=true`
+func GetTokensListHandle(w http.ResponseWriter, r *http.Request) {
+	currentUser := identity.FromRequest(r)
+
+	username := currentUser.Username
+	if r.URL.Query().Get("all") == "true" {
+		if currentUser.Role != users.RoleAdmin {
+			http.Error(w, "Forbidden: admin role required", http.StatusForbidden)
+			return
+		}
+		username = ""
+	}
+
+	tokensListByte, err := json.Marshal(tokens.List(username))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to json marshal tokens list: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(tokensListByte)
+}
@@ -0,0 +1,43 @@
+package apiUsers
+
+import (
+	"net/http"
+	"runny-code/audit"
+	"runny-code/identity"
+	"runny-code/sessions"
+	"runny-code/users"
+)
+
+// ChangePasswordHandle changes the password of the logged in user, which requires the current password,
+// or of any user when done by an admin. Other sessions of the user are ended
+func ChangePasswordHandle(w http.ResponseWriter, r *http.Request) {
+	username := r.PathValue("username")
+	password := r.FormValue("password")
+	currentUser := identity.FromRequest(r)
+
+	if username == currentUser.Username {
+		_, err := users.Authenticate(username, r.FormValue("currentPassword"))
+		if err != nil {
+			audit.Record(r, "user.password", username, err, nil)
+			http.Error(w, "Current password is incorrect", http.StatusForbidden)
+			return
+		}
+	} else if !requireAdmin(w, r) {
+		return
+	}
+
+	err := users.ChangePassword(username, password)
+	audit.Record(r, "user.password", username, err, nil)
+	if err != nil {
+		http.Error(w, err.Error(), userErrorStatus(err))
+		return
+	}
+
+	keepID := ""
+	if username == currentUser.Username {
+		keepID = currentUser.SessionID
+	}
+	sessions.DeleteForUser(username, keepID)
+
+	w.Write([]byte("Password changed"))
+}
@@ -0,0 +1,29 @@
+package apiUsers
+
+import (
+	"net/http"
+	"runny-code/identity"
+	"runny-code/users"
+)
+
+// requireAdmin responds with forbidden when the user of the request is not an admin
+func requireAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if identity.FromRequest(r).Role != users.RoleAdmin {
+		http.Error(w, "Forbidden: admin role required", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+func userErrorStatus(err error) int {
+	switch err {
+	case users.ErrUserNotFound:
+		return http.StatusNotFound
+	case users.ErrUserExists, users.ErrLastAdmin, users.Err2FAEnabled, users.Err2FANotEnabled, users.ErrExternalUser:
+		return http.StatusConflict
+	case users.ErrInvalidCode:
+		return http.StatusForbidden
+	default:
+		return http.StatusBadRequest
+	}
+}
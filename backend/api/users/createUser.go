@@ -0,0 +1,39 @@
+package apiUsers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runny-code/audit"
+	"runny-code/users"
+)
+
+func CreateUserHandle(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	username := r.FormValue("username")
+	password := r.FormValue("password")
+	role := r.FormValue("role")
+	if role == "" {
+		role = users.RoleOperator
+	}
+
+	userInfo, err := users.Create(username, password, role)
+	audit.Record(r, "user.create", username, err, map[string]string{"role": role})
+	if err != nil {
+		http.Error(w, err.Error(), userErrorStatus(err))
+		return
+	}
+
+	userByte, err := json.Marshal(userInfo)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to json marshal user: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(userByte)
+}
@@ -0,0 +1,20 @@
+package apiUsers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runny-code/identity"
+)
+
+// CurrentUserHandle returns the username and role of the logged in user
+func CurrentUserHandle(w http.ResponseWriter, r *http.Request) {
+	userByte, err := json.Marshal(identity.FromRequest(r))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to json marshal user: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(userByte)
+}
@@ -0,0 +1,43 @@
+package apiUsers
+
+import (
+	"net/http"
+	"runny-code/audit"
+	"runny-code/sessions"
+	"runny-code/users"
+)
+
+// DisableUserHandle blocks the login of a user and ends its sessions
+func DisableUserHandle(w http.ResponseWriter, r *http.Request) {
+	setDisabled(w, r, true)
+}
+
+func EnableUserHandle(w http.ResponseWriter, r *http.Request) {
+	setDisabled(w, r, false)
+}
+
+func setDisabled(w http.ResponseWriter, r *http.Request, disabled bool) {
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	username := r.PathValue("username")
+	action := "user.enable"
+	if disabled {
+		action = "user.disable"
+	}
+
+	err := users.SetDisabled(username, disabled)
+	audit.Record(r, action, username, err, nil)
+	if err != nil {
+		http.Error(w, err.Error(), userErrorStatus(err))
+		return
+	}
+
+	if disabled {
+		sessions.DeleteForUser(username, "")
+		w.Write([]byte("User disabled"))
+		return
+	}
+	w.Write([]byte("User enabled"))
+}
@@ -0,0 +1,122 @@
+package apiUsers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runny-code/audit"
+	"runny-code/identity"
+	"runny-code/settings"
+	"runny-code/users"
+)
+
+type EnrollTwoFactorResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"` // otpauth:// URI to show as a QR code
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"` // shown once
+}
+
+// EnrollTwoFactorHandle starts TOTP enrollment for the logged in user, confirmed with `POST /2fa/confirm`
+func EnrollTwoFactorHandle(w http.ResponseWriter, r *http.Request) {
+	secret, uri, err := users.BeginTOTPEnrollment(identity.FromRequest(r).Username)
+	if err != nil {
+		http.Error(w, err.Error(), userErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, EnrollTwoFactorResponse{Secret: secret, URI: uri})
+}
+
+// ConfirmTwoFactorHandle enables 2FA when the code of the enrolled secret is correct
+func ConfirmTwoFactorHandle(w http.ResponseWriter, r *http.Request) {
+	username := identity.FromRequest(r).Username
+
+	codes, err := users.ConfirmTOTP(username, r.FormValue("code"))
+	audit.Record(r, "user.2fa.enable", username, err, nil)
+	if err != nil {
+		http.Error(w, err.Error(), userErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// RegenerateRecoveryCodesHandle replaces the recovery codes, requires a current code
+func RegenerateRecoveryCodesHandle(w http.ResponseWriter, r *http.Request) {
+	username := identity.FromRequest(r).Username
+
+	err := users.VerifySecondFactor(username, r.FormValue("code"))
+	if err != nil {
+		http.Error(w, err.Error(), userErrorStatus(err))
+		return
+	}
+
+	codes, err := users.RegenerateRecoveryCodes(username)
+	audit.Record(r, "user.2fa.recovery", username, err, nil)
+	if err != nil {
+		http.Error(w, err.Error(), userErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// DisableTwoFactorHandle removes 2FA from the logged in user, requires the password and a current code
+func DisableTwoFactorHandle(w http.ResponseWriter, r *http.Request) {
+	username := identity.FromRequest(r).Username
+
+	if settings.Current().Require2FA {
+		http.Error(w, "Two-factor authentication is mandatory", http.StatusConflict)
+		return
+	}
+
+	_, err := users.Authenticate(username, r.FormValue("password"))
+	if err == nil {
+		err = users.VerifySecondFactor(username, r.FormValue("code"))
+	}
+	if err != nil {
+		audit.Record(r, "user.2fa.disable", username, err, nil)
+		http.Error(w, "Incorrect password or code", http.StatusForbidden)
+		return
+	}
+
+	err = users.DisableTOTP(username)
+	audit.Record(r, "user.2fa.disable", username, err, nil)
+	if err != nil {
+		http.Error(w, err.Error(), userErrorStatus(err))
+		return
+	}
+
+	w.Write([]byte("Two-factor authentication disabled"))
+}
+
+// ResetTwoFactorHandle removes 2FA from a user who lost their device
+func ResetTwoFactorHandle(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	username := r.PathValue("username")
+	err := users.DisableTOTP(username)
+	audit.Record(r, "user.2fa.reset", username, err, nil)
+	if err != nil {
+		http.Error(w, err.Error(), userErrorStatus(err))
+		return
+	}
+
+	w.Write([]byte("Two-factor authentication reset"))
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	responseByte, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to json marshal response: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(responseByte)
+}
@@ -0,0 +1,23 @@
+package apiUsers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runny-code/users"
+)
+
+func GetUsersListHandle(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	usersListByte, err := json.Marshal(users.List())
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to json marshal users list: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(usersListByte)
+}
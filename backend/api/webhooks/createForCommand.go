@@ -3,6 +3,8 @@ package apiWebhooks
 import (
 	"fmt"
 	"net/http"
+	apiPermissions "runny-code/api/permissions"
+	"runny-code/audit"
 	"runny-code/commands"
 	"runny-code/webhooks"
 )
@@ -20,11 +22,14 @@ func CreateForCommand(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// check if the command exists
-	foundCommand := commands.FindCommand(commands.ParsedCommandsList, commandName)
+	foundCommand := commands.FindCommand(commands.List(), commandName)
 	if foundCommand == nil {
 		http.Error(w, fmt.Sprintf("Command '%s' does not exists", commandName), http.StatusBadRequest)
 		return
 	}
+	if !apiPermissions.AllowCommand(w, r, foundCommand.Group, foundCommand.Name) {
+		return
+	}
 
 	// create the webhook
 	webhookEntry := webhooks.WebhookEntry{
@@ -33,6 +38,7 @@ func CreateForCommand(w http.ResponseWriter, r *http.Request) {
 	}
 
 	err := webhooks.AddEntry(&webhookEntry)
+	audit.Record(r, "webhook.create", commandName, err, nil)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
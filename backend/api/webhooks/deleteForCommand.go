@@ -2,6 +2,9 @@ package apiWebhooks
 
 import (
 	"net/http"
+	apiPermissions "runny-code/api/permissions"
+	"runny-code/audit"
+	"runny-code/commands"
 	"runny-code/webhooks"
 )
 
@@ -17,8 +20,18 @@ func DeleteForCommand(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// the webhook of a command that no longer exists can be cleaned up by anyone managing commands
+	foundCommand := commands.FindCommand(commands.List(), commandName)
+	if foundCommand != nil && !apiPermissions.AllowCommand(w, r, foundCommand.Group, foundCommand.Name) {
+		return
+	}
+	if foundCommand == nil && !apiPermissions.AllowCommandName(w, r, commandName) {
+		return
+	}
+
 	// delete the webhook
 	err := webhooks.DeleteEntry(commandName, command)
+	audit.Record(r, "webhook.delete", commandName, err, nil)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -3,6 +3,7 @@ package apiWebhooks
 import (
 	"fmt"
 	"net/http"
+	apiPermissions "runny-code/api/permissions"
 	"runny-code/commands"
 	"runny-code/webhooks"
 )
@@ -20,15 +21,18 @@ func GetForCommand(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// check if the command exists
-	foundCommand := commands.FindCommand(commands.ParsedCommandsList, commandName)
+	foundCommand := commands.FindCommand(commands.List(), commandName)
 	if foundCommand == nil {
 		http.Error(w, fmt.Sprintf("Command '%s' does not exists", commandName), http.StatusBadRequest)
 		return
 	}
+	if !apiPermissions.AllowCommand(w, r, foundCommand.Group, foundCommand.Name) {
+		return
+	}
 
 	// get the webhook for the command
 	var webhookEntry *webhooks.WebhookEntry
-	for _, entry := range webhooks.WebhookEntries {
+	for _, entry := range webhooks.List() {
 		if entry.CommandName == commandName && entry.Command == command {
 			webhookEntry = &entry
 			break
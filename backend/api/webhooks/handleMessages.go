@@ -1,8 +1,13 @@
 package apiWebhooks
 
 import (
+	"errors"
 	"net/http"
+	apiPermissions "runny-code/api/permissions"
 	"runny-code/commands"
+	"runny-code/common"
+	"runny-code/identity"
+	"runny-code/jobs"
 	"runny-code/webhooks"
 )
 
@@ -11,7 +16,7 @@ func HandleMessages(w http.ResponseWriter, r *http.Request) {
 
 	// Find the webhook
 	var webhookEntry *webhooks.WebhookEntry
-	for _, entry := range webhooks.WebhookEntries {
+	for _, entry := range webhooks.List() {
 		if entry.UUID == uuid {
 			webhookEntry = &entry
 			break
@@ -23,18 +28,23 @@ func HandleMessages(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Find the command
-	var parsedCommand *commands.ParsedCommand
-	for _, cmd := range commands.ParsedCommandsList {
-		if cmd.Name == webhookEntry.CommandName && cmd.Command == webhookEntry.Command {
-			parsedCommand = &cmd
-			break
-		}
-	}
+	parsedCommand := commands.Lookup(webhookEntry.CommandName, webhookEntry.Command)
 	if parsedCommand == nil {
 		http.Error(w, "Command not found", http.StatusNotFound)
 		return
 	}
 
+	// callers authenticated with a session, token or client certificate are limited by their role
+	caller := identity.FromRequest(r)
+	if caller.Role != "" && !apiPermissions.CanExecute(caller, parsedCommand.Group, parsedCommand.Name) {
+		http.Error(w, "Forbidden: not allowed to execute this command", http.StatusForbidden)
+		return
+	}
+	actor := "webhook"
+	if caller.Username != "" {
+		actor = caller.Username
+	}
+
 	// get command arguments (input)
 	data := make(map[string]string)
 	queryParams := r.URL.Query()
@@ -42,16 +52,22 @@ func HandleMessages(w http.ResponseWriter, r *http.Request) {
 		data[key] = values[0]
 	}
 
-	commandToExecute, err := commands.FillCommand(parsedCommand.Command, parsedCommand.Variables, data)
-	if err != nil {
+	job, err := jobs.Create(parsedCommand, jobs.Origin{Source: jobs.SourceWebhook, Actor: actor, IP: common.ClientIP(r)}, "", data)
+	if errors.Is(err, jobs.ErrSaveFailed) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("X-Job-Id", job.ID)
 
-	output, err := commands.SShExecute(commandToExecute)
+	err = jobs.Run(r.Context(), job, nil)
+	result, _ := jobs.Get(job.ID)
 	if err != nil {
 		http.Error(w, "Failed to execute command", http.StatusInternalServerError)
 	}
 
-	w.Write(output)
+	w.Write([]byte(result.Output))
 }
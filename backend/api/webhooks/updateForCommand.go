@@ -1,7 +1,11 @@
 package apiWebhooks
 
 import (
+	"fmt"
 	"net/http"
+	apiPermissions "runny-code/api/permissions"
+	"runny-code/audit"
+	"runny-code/commands"
 	"runny-code/webhooks"
 )
 
@@ -27,7 +31,25 @@ func UpdateForCommand(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// the webhook moves to the new command, which must exist, the old one may already be gone
+	newFoundCommand := commands.FindCommand(commands.List(), newCommandName)
+	if newFoundCommand == nil {
+		http.Error(w, fmt.Sprintf("Command '%s' does not exists", newCommandName), http.StatusBadRequest)
+		return
+	}
+	if !apiPermissions.AllowCommand(w, r, newFoundCommand.Group, newFoundCommand.Name) {
+		return
+	}
+	oldFoundCommand := commands.FindCommand(commands.List(), oldCommandName)
+	if oldFoundCommand != nil && !apiPermissions.AllowCommand(w, r, oldFoundCommand.Group, oldFoundCommand.Name) {
+		return
+	}
+	if oldFoundCommand == nil && !apiPermissions.AllowCommandName(w, r, oldCommandName) {
+		return
+	}
+
 	err := webhooks.UpdateEntry(oldCommandName, oldCommand, newCommandName, newCommand)
+	audit.Record(r, "webhook.update", oldCommandName, err, map[string]string{"newCommandName": newCommandName})
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
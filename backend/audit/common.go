@@ -0,0 +1,36 @@
+package audit
+
+import (
+	"sync"
+	"time"
+)
+
+// Results
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+)
+
+type Entry struct {
+	Time    time.Time         `json:"time"`
+	Actor   string            `json:"actor"`
+	IP      string            `json:"ip"`
+	Action  string            `json:"action"`
+	Target  string            `json:"target"`
+	Result  string            `json:"result"`
+	Error   string            `json:"error,omitempty"`
+	Details map[string]string `json:"details,omitempty"`
+}
+
+// Filter selects entries in Query, empty fields match everything
+type Filter struct {
+	Actor  string
+	Action string // matches the action or its prefix, e.g. "file." matches all file actions
+	Target string // matches entries whose target contains it
+	Result string
+	Since  time.Time
+	Until  time.Time
+	Limit  int
+}
+
+var auditMutex sync.Mutex
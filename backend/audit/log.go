@@ -0,0 +1,56 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"runny-code/common"
+	"runny-code/identity"
+	"time"
+)
+
+// Log appends an entry to the audit log as a JSON line
+func Log(entry Entry) error {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now().UTC()
+	}
+
+	jsonBytes, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	auditMutex.Lock()
+	defer auditMutex.Unlock()
+
+	file, err := os.OpenFile(common.AuditFile, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.Write(append(jsonBytes, '\n'))
+	return err
+}
+
+// Record logs an action made by the user of the request, err decides the result
+// Failures to write the audit log are printed since the action already happened
+func Record(r *http.Request, action string, target string, err error, details map[string]string) {
+	entry := Entry{
+		Actor:   identity.FromRequest(r).Username,
+		IP:      common.ClientIP(r),
+		Action:  action,
+		Target:  target,
+		Result:  ResultSuccess,
+		Details: details,
+	}
+	if err != nil {
+		entry.Result = ResultFailure
+		entry.Error = err.Error()
+	}
+
+	if logErr := Log(entry); logErr != nil {
+		fmt.Fprintf(os.Stderr, "failed to write audit log: %s\n", logErr)
+	}
+}
@@ -0,0 +1,71 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"runny-code/common"
+	"slices"
+	"strings"
+)
+
+// Query reads the audit log and returns the matching entries, newest first
+func Query(filter Filter) ([]Entry, error) {
+	auditMutex.Lock()
+	defer auditMutex.Unlock()
+
+	entries := []Entry{}
+
+	file, err := os.Open(common.AuditFile)
+	if os.IsNotExist(err) {
+		return entries, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue // skip corrupted lines instead of failing the whole query
+		}
+		if filter.matches(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	slices.Reverse(entries)
+	if filter.Limit > 0 && len(entries) > filter.Limit {
+		entries = entries[:filter.Limit]
+	}
+
+	return entries, nil
+}
+
+func (f Filter) matches(entry Entry) bool {
+	if f.Actor != "" && entry.Actor != f.Actor {
+		return false
+	}
+	if f.Action != "" && entry.Action != f.Action && !(strings.HasSuffix(f.Action, ".") && strings.HasPrefix(entry.Action, f.Action)) {
+		return false
+	}
+	if f.Target != "" && !strings.Contains(entry.Target, f.Target) {
+		return false
+	}
+	if f.Result != "" && entry.Result != f.Result {
+		return false
+	}
+	if !f.Since.IsZero() && entry.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && entry.Time.After(f.Until) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,119 @@
+package certs
+
+import (
+	"crypto/tls"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runny-code/common"
+	"sync"
+	"time"
+)
+
+// reloadInterval is how often the files are checked for changes, e.g. after a renewal
+const reloadInterval = 10 * time.Second
+
+// KeyPair is a TLS certificate loaded from files, reloaded when they change without restarting the server
+type KeyPair struct {
+	certFile string
+	keyFile  string
+
+	mutex       sync.RWMutex
+	certificate *tls.Certificate
+	stamp       string // modification times and sizes of the loaded files
+}
+
+// LoadKeyPair loads the PEM certificate and key, relative paths are resolved from the config directory
+func LoadKeyPair(certFile string, keyFile string) (*KeyPair, error) {
+	if certFile == "" || keyFile == "" {
+		return nil, fmt.Errorf("both a certificate and a key file are required")
+	}
+
+	keyPair := &KeyPair{certFile: resolvePath(certFile), keyFile: resolvePath(keyFile)}
+	err := keyPair.reload()
+	if err != nil {
+		return nil, err
+	}
+
+	go keyPair.watch()
+	return keyPair, nil
+}
+
+// TLSConfig serves the current certificate on every handshake
+func (k *KeyPair) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: k.GetCertificate,
+	}
+}
+
+func (k *KeyPair) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+	return k.certificate, nil
+}
+
+// watch reloads the files when they change, a failed reload keeps the previous certificate and is retried
+// e.g. when the certificate was written but not the matching key yet
+func (k *KeyPair) watch() {
+	ticker := time.NewTicker(reloadInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		stamp, err := k.filesStamp()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to check the TLS certificate: %s\n", err)
+			continue
+		}
+
+		k.mutex.RLock()
+		changed := stamp != k.stamp
+		k.mutex.RUnlock()
+		if !changed {
+			continue
+		}
+
+		err = k.reload()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to reload the TLS certificate, keeping the previous one: %s\n", err)
+		}
+	}
+}
+
+func (k *KeyPair) reload() error {
+	stamp, err := k.filesStamp()
+	if err != nil {
+		return err
+	}
+
+	certificate, err := tls.LoadX509KeyPair(k.certFile, k.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load the TLS certificate: %w", err)
+	}
+
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	k.certificate = &certificate
+	k.stamp = stamp
+	return nil
+}
+
+// filesStamp changes whenever one of the files is replaced or rewritten, symlinks are followed
+func (k *KeyPair) filesStamp() (string, error) {
+	stamp := ""
+	for _, file := range []string{k.certFile, k.keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return "", err
+		}
+		stamp += fmt.Sprintf("%d:%d;", info.ModTime().UnixNano(), info.Size())
+	}
+	return stamp, nil
+}
+
+func resolvePath(file string) string {
+	if filepath.IsAbs(file) {
+		return file
+	}
+	return filepath.Join(common.ConfigDir, file)
+}
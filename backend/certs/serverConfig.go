@@ -0,0 +1,58 @@
+package certs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// ServerConfig returns the TLS config of a listener, nil to serve plain HTTP when no certificate is set
+// clientAuth is off, optional (verified when sent) or require, client certificates must be signed by the CA file
+func ServerConfig(certFile string, keyFile string, clientAuth string, clientCAFile string) (*tls.Config, error) {
+	if certFile == "" && keyFile == "" {
+		if clientAuth != "off" {
+			return nil, fmt.Errorf("client certificates need TLS, set a certificate and key file")
+		}
+		return nil, nil
+	}
+
+	keyPair, err := LoadKeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	config := keyPair.TLSConfig()
+
+	switch clientAuth {
+	case "off":
+		return config, nil
+	case "optional":
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+	case "require":
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	default:
+		return nil, fmt.Errorf("unknown client certificate mode '%s', expected off, optional or require", clientAuth)
+	}
+
+	if clientCAFile == "" {
+		return nil, fmt.Errorf("client certificates need a CA file")
+	}
+	config.ClientCAs, err = loadCAPool(clientCAFile)
+	if err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func loadCAPool(caFile string) (*x509.CertPool, error) {
+	pemBytes, err := os.ReadFile(resolvePath(caFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the client CA file: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pemBytes) {
+		return nil, fmt.Errorf("the client CA file has no PEM certificates")
+	}
+	return pool, nil
+}
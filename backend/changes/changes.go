@@ -0,0 +1,47 @@
+package changes
+
+import "sync"
+
+const (
+	Commands = "commands"
+	Webhooks = "webhooks"
+)
+
+// Event tells the UIs that a list changed and must be fetched again
+type Event struct {
+	Type    string `json:"type"`    // commands | webhooks
+	Version uint64 `json:"version"` // of the list, to ignore events about an already fetched one
+}
+
+var (
+	subscribersMutex sync.Mutex
+	subscribers      = map[chan Event]struct{}{}
+)
+
+// Subscribe returns a channel receiving the events and a function to stop receiving them
+func Subscribe() (<-chan Event, func()) {
+	events := make(chan Event, 8)
+
+	subscribersMutex.Lock()
+	subscribers[events] = struct{}{}
+	subscribersMutex.Unlock()
+
+	return events, func() {
+		subscribersMutex.Lock()
+		delete(subscribers, events)
+		subscribersMutex.Unlock()
+	}
+}
+
+// Publish sends an event to all subscribers, a subscriber that is not keeping up misses it
+func Publish(eventType string, version uint64) {
+	subscribersMutex.Lock()
+	defer subscribersMutex.Unlock()
+
+	for events := range subscribers {
+		select {
+		case events <- Event{Type: eventType, Version: version}:
+		default:
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+)
+
+const usage = `Usage: runny-code [command]
+
+Without a command, starts the server.
+
+Commands:
+  convert [-force] <input> <output>  Convert between commands.txt and YAML (.yaml, .yml) or JSON (.json) catalogs
+  lint [files...]                    Report errors and warnings in the commands files, the configured ones by default
+  help                               Show this help
+`
+
+// Run runs a subcommand instead of the server and returns the exit code
+func Run(args []string) int {
+	switch args[0] {
+	case "convert":
+		return convert(args[1:])
+	case "lint":
+		return lint(args[1:])
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+		return 0
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command '%s'\n\n%s", args[0], usage)
+		return 2
+	}
+}
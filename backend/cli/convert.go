@@ -0,0 +1,36 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"runny-code/commands"
+)
+
+// convert writes the commands of a commands.txt file or catalog to another format, e.g. `convert commands.txt commands.yaml`
+func convert(args []string) int {
+	flags := flag.NewFlagSet("convert", flag.ContinueOnError)
+	force := flags.Bool("force", false, "overwrite the output file")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 2 {
+		fmt.Fprintln(os.Stderr, "usage: runny-code convert [-force] <input> <output>")
+		return 2
+	}
+	inputPath, outputPath := flags.Arg(0), flags.Arg(1)
+
+	if _, err := os.Stat(outputPath); err == nil && !*force {
+		fmt.Fprintf(os.Stderr, "'%s' already exists, use -force to overwrite it\n", outputPath)
+		return 1
+	}
+
+	count, err := commands.ConvertFile(inputPath, outputPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to convert: %s\n", err)
+		return 1
+	}
+
+	fmt.Printf("Converted %d commands from %s to %s\n", count, inputPath, outputPath)
+	return 0
+}
@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"runny-code/commands"
+)
+
+// lint checks commands files, the configured ones by default, and exits with 1 when there are errors
+func lint(args []string) int {
+	flags := flag.NewFlagSet("lint", flag.ContinueOnError)
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	files := flags.Args()
+	if len(files) == 0 {
+		var err error
+		if files, err = commands.CommandsFiles(); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to find commands files: %s\n", err)
+			return 1
+		}
+	}
+	if len(files) == 0 {
+		fmt.Fprintln(os.Stderr, "no commands file found")
+		return 1
+	}
+
+	diagnostics := commands.Lint(files)
+	errorsCount := 0
+	for _, diagnostic := range diagnostics {
+		fmt.Println(diagnostic.String())
+		if diagnostic.Severity == commands.SeverityError {
+			errorsCount++
+		}
+	}
+
+	if len(diagnostics) > 0 {
+		fmt.Printf("\n%d errors, %d warnings\n", errorsCount, len(diagnostics)-errorsCount)
+	}
+	if errorsCount > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,18 @@
+package clientcerts
+
+import (
+	"errors"
+	"sync"
+)
+
+var ErrNotMapped = errors.New("the client certificate is not mapped to a user or role")
+
+// Mapping gives the callers of a client certificate the identity of a user, or only a role
+type Mapping struct {
+	Subject  string `json:"subject"`            // pattern matched against the CN and the DNS, email and URI SANs, e.g. `ci.internal` or `*.build.internal`
+	Username string `json:"username,omitempty"` // an existing user
+	Role     string `json:"role,omitempty"`     // or a role, the username is then `cert:` and the matched name
+}
+
+var mappingsList []Mapping
+var mappingsMutex sync.Mutex
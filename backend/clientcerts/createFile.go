@@ -0,0 +1,14 @@
+package clientcerts
+
+import (
+	"os"
+	"runny-code/common"
+)
+
+func CreateFile() error {
+	_, err := os.Stat(common.ClientCertsFile)
+	if os.IsNotExist(err) {
+		return os.WriteFile(common.ClientCertsFile, []byte("[]"), 0644)
+	}
+	return err
+}
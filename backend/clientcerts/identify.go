@@ -0,0 +1,50 @@
+package clientcerts
+
+import (
+	"crypto/x509"
+	"path"
+	"runny-code/identity"
+	"runny-code/users"
+)
+
+// Identify returns the identity of a verified client certificate, the first mapping matching one of its names wins
+func Identify(cert *x509.Certificate) (identity.Identity, error) {
+	mappingsMutex.Lock()
+	mappings := mappingsList
+	mappingsMutex.Unlock()
+
+	names := subjectNames(cert)
+	for _, mapping := range mappings {
+		for _, name := range names {
+			if matched, _ := path.Match(mapping.Subject, name); !matched {
+				continue
+			}
+
+			if mapping.Role != "" {
+				return identity.Identity{Username: identity.CertificatePrefix + name, Role: mapping.Role, Method: identity.MethodCertificate}, nil
+			}
+
+			user, found := users.Find(mapping.Username)
+			if !found || user.Disabled {
+				return identity.Identity{}, users.ErrInvalidCredentials
+			}
+			return identity.Identity{Username: user.Username, Role: user.Role, Method: identity.MethodCertificate}, nil
+		}
+	}
+
+	return identity.Identity{}, ErrNotMapped
+}
+
+// subjectNames returns the common name then the subject alternative names
+func subjectNames(cert *x509.Certificate) []string {
+	names := []string{}
+	if cert.Subject.CommonName != "" {
+		names = append(names, cert.Subject.CommonName)
+	}
+	names = append(names, cert.DNSNames...)
+	names = append(names, cert.EmailAddresses...)
+	for _, uri := range cert.URIs {
+		names = append(names, uri.String())
+	}
+	return names
+}
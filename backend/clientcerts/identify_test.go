@@ -0,0 +1,65 @@
+package clientcerts
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"os"
+	"path/filepath"
+	"runny-code/users"
+	"testing"
+)
+
+func TestIdentify(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"config", "backend"} {
+		if err := os.Mkdir(filepath.Join(root, dir), 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	usersFile := `[{"username":"deploy","role":"operator"},{"username":"old","role":"operator","disabled":true}]`
+	if err := os.WriteFile(filepath.Join(root, "config", "users.json"), []byte(usersFile), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(filepath.Join(root, "backend"))
+	if err := users.ReadFile(); err != nil {
+		t.Fatal(err)
+	}
+
+	mappingsList = []Mapping{
+		{Subject: "deploy.internal", Username: "deploy"},
+		{Subject: "old.internal", Username: "old"},
+		{Subject: "*.build.internal", Role: users.RoleViewer},
+	}
+	t.Cleanup(func() { mappingsList = nil })
+
+	tests := []struct {
+		name         string
+		commonName   string
+		dnsNames     []string
+		wantUsername string
+		wantRole     string
+		wantErr      error
+	}{
+		{"mapped to a user", "deploy.internal", nil, "deploy", users.RoleOperator, nil},
+		{"mapped to a role", "ci.build.internal", nil, "cert:ci.build.internal", users.RoleViewer, nil},
+		{"role matched on a SAN", "", []string{"ci.build.internal"}, "cert:ci.build.internal", users.RoleViewer, nil},
+		{"role named like a user", "deploy.build.internal", nil, "cert:deploy.build.internal", users.RoleViewer, nil},
+		{"disabled user", "old.internal", nil, "", "", users.ErrInvalidCredentials},
+		{"not mapped", "other.internal", nil, "", "", ErrNotMapped},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cert := &x509.Certificate{Subject: pkix.Name{CommonName: test.commonName}, DNSNames: test.dnsNames}
+
+			got, err := Identify(cert)
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("got error %v, want %v", err, test.wantErr)
+			}
+			if got.Username != test.wantUsername || got.Role != test.wantRole {
+				t.Errorf("got %s with role %s, want %s with role %s", got.Username, got.Role, test.wantUsername, test.wantRole)
+			}
+		})
+	}
+}
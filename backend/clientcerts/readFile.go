@@ -0,0 +1,41 @@
+package clientcerts
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"runny-code/common"
+	"runny-code/users"
+)
+
+func ReadFile() error {
+	file, err := os.Open(common.ClientCertsFile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var entries []Mapping
+	err = json.NewDecoder(file).Decode(&entries)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if _, err := path.Match(entry.Subject, ""); err != nil || entry.Subject == "" {
+			return fmt.Errorf("invalid client certificate subject pattern '%s'", entry.Subject)
+		}
+		if (entry.Username == "") == (entry.Role == "") {
+			return fmt.Errorf("client certificate '%s' must map to either a username or a role", entry.Subject)
+		}
+		if entry.Role != "" && !users.IsValidRole(entry.Role) {
+			return fmt.Errorf("client certificate '%s' has an unknown role '%s'", entry.Subject, entry.Role)
+		}
+	}
+
+	mappingsMutex.Lock()
+	defer mappingsMutex.Unlock()
+	mappingsList = entries
+	return nil
+}
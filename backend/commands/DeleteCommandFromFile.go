@@ -1,78 +1,71 @@
 package commands
 
 import (
-	"bufio"
-	"os"
-	"path"
-	"slices"
+	"fmt"
+	"path/filepath"
+	"runny-code/common"
 	"strings"
 )
 
 // RemoveCommandFromFile removes a specific command and associated metadata from a file.
+// Multi-line commands are removed with all their lines, other lines are kept as written.
 func RemoveCommandFromFile(filePath string, targetCommand ParsedCommand) error {
-	file, err := os.Open(filePath)
+	if IsCatalogFile(filePath) {
+		return removeCommandFromCatalog(filePath, targetCommand)
+	}
+
+	entries, err := readFileEntries(filePath)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
-
-	scanner := bufio.NewScanner(file)
-	fileStrLines:= []string{}
-	linesIndicesToRemove := []int{}
 
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-
-		// Skip empty, comment, and directive lines
-		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "@") {
-			fileStrLines = append(fileStrLines, line)
+	removed := false
+	keptEntries := []fileEntry{}
+	for _, entry := range entries {
+		// commands may share the same command line, only the one with the name and group is removed
+		directives := directivesAbove(keptEntries)
+		if removed || !entry.isCommand() || entry.command != targetCommand.Command || !hasNameAndGroup(directives, targetCommand) {
+			keptEntries = append(keptEntries, entry)
 			continue
 		}
 
-		// not our target command
-		if line != targetCommand.Command {
-			fileStrLines = append(fileStrLines, line)
-			continue
-		}
-
-		for  i, previousLine := range slices.Backward(fileStrLines) {
-			if !strings.HasPrefix(previousLine, "@") {
-				break
-			}
-			linesIndicesToRemove = append(linesIndicesToRemove, i)
-		}
+		// drop the directives right above the command
+		keptEntries = keptEntries[:len(keptEntries)-len(directives)]
+		removed = true
 	}
-
-	// Remove the lines
-	for _, index := range linesIndicesToRemove {
-		fileStrLines = slices.Delete(fileStrLines, index, index+1)
-	}
-
-	// Check for scanning errors
-	if err := scanner.Err(); err != nil {
-		return err
+	if !removed {
+		return fmt.Errorf("command '%s' not found in %s", targetCommand.Name, filepath.Base(filePath))
 	}
 
 	// Write the updated content back to the file
-	tempFile, err := os.CreateTemp(path.Dir(filePath), "tmp_commands")
-	if err != nil {
-		return err
-	}
-	defer os.Remove(tempFile.Name())
-
-	writer := bufio.NewWriter(tempFile)
-	for _, line := range fileStrLines {
-		_, err := writer.WriteString(line + "\n")
-		if err != nil {
-			return err
+	var content strings.Builder
+	for _, entry := range keptEntries {
+		for _, line := range entry.rawLines {
+			content.WriteString(line + "\n")
 		}
 	}
-	writer.Flush()
 
-	// Replace the original file with the temp file
-	if err := os.Rename(tempFile.Name(), filePath); err != nil {
-		return err
+	return common.WriteFileAtomic(filePath, []byte(content.String()), 0644)
+}
+
+// directivesAbove returns the directives at the end of the entries, those of the next command
+func directivesAbove(entries []fileEntry) []fileEntry {
+	start := len(entries)
+	for start > 0 && !entries[start-1].isCommand() && strings.HasPrefix(entries[start-1].line, "@") {
+		start--
 	}
+	return entries[start:]
+}
 
-	return nil
+// hasNameAndGroup reports whether the directives give the name and group of the command, the first of each is used as when parsing
+func hasNameAndGroup(directives []fileEntry, command ParsedCommand) bool {
+	name, group := "", ""
+	for i := len(directives) - 1; i >= 0; i-- {
+		if match := NameDirectiveRe.FindStringSubmatch(directives[i].line); len(match) > 0 {
+			name = strings.TrimSpace(match[1])
+		} else if match := GroupDirectiveRe.FindStringSubmatch(directives[i].line); len(match) > 0 {
+			group = strings.TrimSpace(match[1])
+		}
+	}
+	return name == command.Name && group == command.Group
 }
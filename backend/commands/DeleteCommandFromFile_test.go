@@ -0,0 +1,49 @@
+package commands
+
+import (
+	"os"
+	"testing"
+)
+
+func TestRemoveCommandFromFileMatchesNameAndGroup(t *testing.T) {
+	filePath := writeTestFile(t, "commands.txt", `@name Restart
+@group web
+systemctl restart ${Service}
+
+@name Restart
+@group db
+@desc Restarts the database
+systemctl restart ${Service}
+
+@name Restart db
+@group db
+systemctl restart ${Service}
+`)
+
+	err := RemoveCommandFromFile(filePath, ParsedCommand{Name: "Restart", Group: "db", Command: "systemctl restart ${Service}"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `@name Restart
+@group web
+systemctl restart ${Service}
+
+
+@name Restart db
+@group db
+systemctl restart ${Service}
+`
+	if string(content) != want {
+		t.Errorf("file is:\n%s\nwant:\n%s", content, want)
+	}
+
+	err = RemoveCommandFromFile(filePath, ParsedCommand{Name: "Restart", Group: "db", Command: "systemctl restart ${Service}"})
+	if err == nil {
+		t.Error("expected removing a missing command to fail")
+	}
+}
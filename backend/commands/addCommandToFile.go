@@ -3,6 +3,7 @@ package commands
 import (
 	"fmt"
 	"os"
+	"runny-code/common"
 	"strings"
 )
 
@@ -10,30 +11,42 @@ type AddCommandInput struct {
 	CommandName string `json:"commandName"`
 	GroupName   string `json:"groupName"`
 	Description string `json:"description"`
+	Executor    string `json:"executor"`
+	Hosts       string `json:"hosts"`
+	Timeout     string `json:"timeout"`
+	TTY         bool   `json:"tty"`
 	Command     string `json:"command"`
 }
 
+// AddCommandToFile appends the command to a commands.txt file, or to a YAML or JSON catalog by its extension
 func AddCommandToFile(filePath string, commandInput AddCommandInput) error {
+	if IsCatalogFile(filePath) {
+		return addCommandToCatalog(filePath, commandInput)
+	}
+
 	file, err := os.ReadFile(filePath)
 	if err != nil {
 		return err
 	}
 
-	commandStr := constructCommandLine(commandInput)
+	commandStr, err := constructCommandLine(commandInput)
+	if err != nil {
+		return err
+	}
 
 	fileStr := string(file)
 	fileStr = strings.TrimRight(fileStr, " \n")
 	fileStr = fmt.Sprintf("%s\n\n%s", fileStr, commandStr)
 
-	err = os.WriteFile(filePath, []byte(fileStr), 0644)
+	return common.WriteFileAtomic(filePath, []byte(fileStr), 0644)
+}
+
+func constructCommandLine(commandInput AddCommandInput) (string, error) {
+	commandBody, err := formatCommandBody(commandInput.Command)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	return nil
-}
-
-func constructCommandLine(commandInput AddCommandInput) string {
 	nameLn := fmt.Sprintf("@name %s\n", commandInput.CommandName)
 
 	groupNameLn := ""
@@ -46,5 +59,25 @@ func constructCommandLine(commandInput AddCommandInput) string {
 		descriptionLn = fmt.Sprintf("@description %s\n", commandInput.Description)
 	}
 
-	return fmt.Sprintf("%s%s%s%s", nameLn, groupNameLn, descriptionLn, commandInput.Command)
+	executorLn := ""
+	if commandInput.Executor != "" {
+		executorLn = fmt.Sprintf("@executor %s\n", commandInput.Executor)
+	}
+
+	hostsLn := ""
+	if commandInput.Hosts != "" {
+		hostsLn = fmt.Sprintf("@host %s\n", commandInput.Hosts)
+	}
+
+	timeoutLn := ""
+	if commandInput.Timeout != "" {
+		timeoutLn = fmt.Sprintf("@timeout %s\n", commandInput.Timeout)
+	}
+
+	ttyLn := ""
+	if commandInput.TTY {
+		ttyLn = "@tty\n"
+	}
+
+	return fmt.Sprintf("%s%s%s%s%s%s%s%s", nameLn, groupNameLn, descriptionLn, executorLn, hostsLn, timeoutLn, ttyLn, commandBody), nil
 }
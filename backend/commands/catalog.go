@@ -0,0 +1,338 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runny-code/common"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CatalogCommand is a command of a YAML or JSON catalog, the same fields as the directives of commands.txt
+type CatalogCommand struct {
+	Name        string   `yaml:"name" json:"name"`
+	Group       string   `yaml:"group,omitempty" json:"group,omitempty"`
+	Description string   `yaml:"description,omitempty" json:"description,omitempty"`
+	Executor    string   `yaml:"executor,omitempty" json:"executor,omitempty"`
+	Hosts       []string `yaml:"hosts,omitempty" json:"hosts,omitempty"`
+	Timeout     string   `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	TTY         bool     `yaml:"tty,omitempty" json:"tty,omitempty"`
+
+	Variables []CatalogVariable `yaml:"variables,omitempty" json:"variables,omitempty"`
+	Command   string            `yaml:"command" json:"command"` // may span several lines, uses the variables as ${name}, undeclared ones are defined with ${...} as in commands.txt
+}
+
+// CatalogVariable declares a variable of a catalog command, it takes precedence over a definition in the command
+type CatalogVariable struct {
+	Name     string   `yaml:"name" json:"name"`
+	Type     string   `yaml:"type,omitempty" json:"type,omitempty"` // one of the value types, "any" when empty
+	Default  string   `yaml:"default,omitempty" json:"default,omitempty"`
+	Optional bool     `yaml:"optional,omitempty" json:"optional,omitempty"`
+	Values   []string `yaml:"values,omitempty" json:"values,omitempty"` // the accepted values, add "*" to only suggest them
+}
+
+type Catalog struct {
+	Commands []CatalogCommand `yaml:"commands" json:"commands"`
+}
+
+// IsCatalogFile reports whether the file is a YAML or JSON catalog rather than a commands.txt file
+func IsCatalogFile(filePath string) bool {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
+
+func isJSONFile(filePath string) bool {
+	return strings.ToLower(filepath.Ext(filePath)) == ".json"
+}
+
+// catalogPosition locates a command of a catalog, for diagnostics
+type catalogPosition struct {
+	line          int
+	column        int
+	nameNode      *yaml.Node // nil when the command has no name
+	valueNode     *yaml.Node // of `command`, nil when missing
+	variablesNode *yaml.Node // of `variables`, nil when missing
+}
+
+var yamlErrorLineRe = regexp.MustCompile(`line (\d+)`)
+
+// readCatalog reads a YAML or JSON catalog with the positions of its commands
+func readCatalog(filePath string) (Catalog, []catalogPosition, []string, error) {
+	fileBytes, err := os.ReadFile(filePath)
+	if err != nil {
+		return Catalog{}, nil, nil, err
+	}
+	lines := strings.Split(strings.ReplaceAll(string(fileBytes), "\r\n", "\n"), "\n")
+
+	readFormat := readYAMLCatalog
+	if isJSONFile(filePath) {
+		readFormat = readJSONCatalog
+	}
+	catalog, root, err := readFormat(filePath, fileBytes)
+	if err != nil {
+		return Catalog{}, nil, nil, err
+	}
+
+	for i, command := range catalog.Commands {
+		// YAML block scalars (`|`) end with a line break that is not part of the command
+		catalog.Commands[i].Command = strings.TrimRight(strings.ReplaceAll(command.Command, "\r\n", "\n"), "\n")
+	}
+
+	return catalog, catalogPositions(&root, len(catalog.Commands)), lines, nil
+}
+
+func readYAMLCatalog(filePath string, fileBytes []byte) (Catalog, yaml.Node, error) {
+	catalog := Catalog{}
+	root := yaml.Node{}
+	err := yaml.Unmarshal(fileBytes, &root)
+	if err == nil && len(root.Content) > 0 {
+		err = root.Content[0].Decode(&catalog)
+	}
+	if err != nil {
+		line := 1
+		if match := yamlErrorLineRe.FindStringSubmatch(err.Error()); match != nil {
+			line, _ = strconv.Atoi(match[1])
+		}
+		return Catalog{}, yaml.Node{}, Diagnostic{File: filePath, Line: line, Column: 1, Severity: SeverityError, Message: strings.TrimPrefix(err.Error(), "yaml: ")}
+	}
+	return catalog, root, nil
+}
+
+func catalogPositions(root *yaml.Node, count int) []catalogPosition {
+	positions := make([]catalogPosition, count)
+	if len(root.Content) == 0 {
+		return positions
+	}
+
+	commandsNode := mappingValue(root.Content[0], "commands")
+	if commandsNode == nil {
+		return positions
+	}
+	for i, item := range commandsNode.Content {
+		if i >= count {
+			break
+		}
+		positions[i] = catalogPosition{line: item.Line, column: item.Column, nameNode: mappingValue(item, "name"), valueNode: mappingValue(item, "command"), variablesNode: mappingValue(item, "variables")}
+	}
+	return positions
+}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// variablePosition returns the line and column of a declared variable
+func (p catalogPosition) variablePosition(index int) (int, int) {
+	if p.variablesNode == nil || index >= len(p.variablesNode.Content) {
+		return p.line, p.column
+	}
+	return p.variablesNode.Content[index].Line, p.variablesNode.Content[index].Column
+}
+
+// commandPosition returns the line and column in the file of an offset in the command
+// Exact for block scalars (`|`) and single line values, the start of the value otherwise
+func (p catalogPosition) commandPosition(lines []string, command string, offset int) (int, int) {
+	if p.valueNode == nil {
+		return p.line, p.column
+	}
+
+	lineIndex, column := offsetPosition(command, offset)
+	switch p.valueNode.Style {
+	case yaml.LiteralStyle:
+		line := p.valueNode.Line + 1 + lineIndex
+		if line-1 < len(lines) {
+			return line, indentOf(lines[line-1]) + column + 1
+		}
+	case 0, yaml.FlowStyle:
+		if !strings.Contains(command, "\n") {
+			return p.valueNode.Line, p.valueNode.Column + column
+		}
+	case yaml.DoubleQuotedStyle, yaml.SingleQuotedStyle:
+		if !strings.Contains(command, "\n") && !strings.Contains(command, "\\") {
+			return p.valueNode.Line, p.valueNode.Column + 1 + column
+		}
+	}
+	return p.valueNode.Line, p.valueNode.Column
+}
+
+// writeCatalog replaces the catalog through a temp file, so a failed write does not leave it half written
+func writeCatalog(filePath string, catalog Catalog) error {
+	if isJSONFile(filePath) {
+		fileBytes, err := json.MarshalIndent(catalog, "", "  ")
+		if err != nil {
+			return err
+		}
+		return common.WriteFileAtomic(filePath, fileBytes, 0644)
+	}
+
+	buffer := bytes.Buffer{}
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(2)
+	err := encoder.Encode(catalog)
+	if err != nil {
+		return err
+	}
+	encoder.Close()
+
+	return common.WriteFileAtomic(filePath, buffer.Bytes(), 0644)
+}
+
+func parseCatalogFile(filePath string) ([]ParsedCommand, error) {
+	catalog, positions, _, err := readCatalog(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	commands := []ParsedCommand{}
+	for i, command := range catalog.Commands {
+		if strings.TrimSpace(command.Command) == "" {
+			return nil, Diagnostic{File: filePath, Line: positions[i].line, Column: positions[i].column, Severity: SeverityError, Message: fmt.Sprintf("command '%s' has no `command`", command.Name)}
+		}
+
+		hostNames := []string{}
+		for _, name := range command.Hosts {
+			name = strings.TrimSpace(name)
+			if name != "" && !slices.Contains(hostNames, name) {
+				hostNames = append(hostNames, name)
+			}
+		}
+
+		commands = append(commands, ParsedCommand{
+			Name:        strings.TrimSpace(command.Name),
+			Group:       strings.TrimSpace(command.Group),
+			Description: strings.TrimSpace(command.Description),
+			Command:     command.Command,
+			Executor:    strings.TrimSpace(command.Executor),
+			Hosts:       hostNames,
+			Timeout:     strings.TrimSpace(command.Timeout),
+			TTY:         command.TTY,
+			Variables:   declaredVariables(command.Variables),
+		})
+	}
+
+	return commands, nil
+}
+
+// declaredVariables parses the `variables` of a catalog command like placeholders, a variable declared twice keeps the first
+func declaredVariables(catalogVariables []CatalogVariable) []ParsedVariable {
+	variables := []ParsedVariable{}
+	for _, catalogVariable := range catalogVariables {
+		name := strings.TrimSpace(catalogVariable.Name)
+		if name == "" || findVariable(&variables, name) != nil {
+			continue
+		}
+
+		variable := ParsedVariable{
+			Name:     name,
+			Optional: catalogVariable.Optional,
+			Type:     verifyType(strings.TrimSpace(catalogVariable.Type)),
+			Default:  catalogVariable.Default,
+		}
+		variable.Values, variable.Restricted = handleValuesList(catalogVariable.Values)
+		if variable.Values == nil {
+			variable.Values = []string{}
+		}
+		variables = append(variables, variable)
+	}
+	return variables
+}
+
+func addCommandToCatalog(filePath string, commandInput AddCommandInput) error {
+	catalog, _, _, err := readCatalog(filePath)
+	if err != nil {
+		return err
+	}
+
+	// the variables defined in the command line are stored in `variables` like the converter does
+	command := strings.ReplaceAll(commandInput.Command, "\r\n", "\n")
+	catalog.Commands = append(catalog.Commands, toCatalogCommand(ParsedCommand{
+		Name:        commandInput.CommandName,
+		Group:       commandInput.GroupName,
+		Description: commandInput.Description,
+		Executor:    commandInput.Executor,
+		Hosts:       parseHostNames(commandInput.Hosts),
+		Timeout:     commandInput.Timeout,
+		TTY:         commandInput.TTY,
+		Command:     command,
+		Variables:   ParseVariables(command),
+	}))
+
+	return writeCatalog(filePath, catalog)
+}
+
+func removeCommandFromCatalog(filePath string, targetCommand ParsedCommand) error {
+	catalog, _, _, err := readCatalog(filePath)
+	if err != nil {
+		return err
+	}
+
+	// commands may share the same command line, only the one with the name is removed
+	index := slices.IndexFunc(catalog.Commands, func(command CatalogCommand) bool {
+		return strings.TrimSpace(command.Name) == targetCommand.Name && command.Command == targetCommand.Command
+	})
+	if index == -1 {
+		return fmt.Errorf("command '%s' not found in %s", targetCommand.Name, filepath.Base(filePath))
+	}
+	catalog.Commands = slices.Delete(catalog.Commands, index, index+1)
+
+	return writeCatalog(filePath, catalog)
+}
+
+// toCatalogCommand is the reverse of parsing, used by the converters
+// The variables move to `variables`, their placeholders are left as ${name}
+func toCatalogCommand(command ParsedCommand) CatalogCommand {
+	variables := []CatalogVariable{}
+	for _, variable := range command.Variables {
+		variables = append(variables, CatalogVariable{
+			Name:     variable.Name,
+			Type:     variable.Type,
+			Default:  variable.Default,
+			Optional: variable.Optional,
+			Values:   variable.Values,
+		})
+	}
+
+	return CatalogCommand{
+		Name:        command.Name,
+		Group:       command.Group,
+		Description: command.Description,
+		Executor:    command.Executor,
+		Hosts:       command.Hosts,
+		Timeout:     command.Timeout,
+		TTY:         command.TTY,
+		Variables:   variables,
+		Command: rewritePlaceholders(command.Command, func(name string, first bool) string {
+			return "${" + name + "}"
+		}),
+	}
+}
+
+// inlineVariables writes the variables into the command for commands.txt, each one is defined by its first placeholder
+// Variables that are not used in the command are dropped
+func inlineVariables(command ParsedCommand) string {
+	return rewritePlaceholders(command.Command, func(name string, first bool) string {
+		variable := findVariable(&command.Variables, name)
+		if !first || variable == nil {
+			return "${" + name + "}"
+		}
+		return placeholderOf(*variable)
+	})
+}
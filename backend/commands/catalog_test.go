@@ -0,0 +1,206 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeTestFile(t *testing.T, name string, content string) string {
+	filePath := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return filePath
+}
+
+func TestPlaceholderOfParsesBack(t *testing.T) {
+	tests := []ParsedVariable{
+		{Name: "Name", Values: []string{}},
+		{Name: "Name", Optional: true, Values: []string{}},
+		{Name: "Count", Type: "int", Default: "5", Values: []string{}},
+		{Name: "Path", Type: "path", Default: "/home", Values: []string{}},
+		{Name: "Env", Default: "dev", Values: []string{"dev", "prod"}, Restricted: true},
+		{Name: "User", Optional: true, Type: "non-numeric", Values: []string{"john", "sara", "*"}},
+	}
+
+	for _, variable := range tests {
+		placeholder := placeholderOf(variable)
+		parsed := ParseVariables(placeholder)
+		if len(parsed) != 1 || !reflect.DeepEqual(parsed[0], variable) {
+			t.Errorf("%s parsed as %+v, want %+v", placeholder, parsed, variable)
+		}
+	}
+}
+
+func TestCatalogDeclaresVariables(t *testing.T) {
+	catalogPath := writeTestFile(t, "commands.yaml", `commands:
+  - name: Deploy
+    variables:
+      - name: Env
+        values: [dev, prod]
+        default: dev
+      - name: Count
+        type: int
+        optional: true
+    command: deploy ${Env} ${Count:any} ${Other=x}
+`)
+
+	parsed, err := ParseCommands(catalogPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []ParsedVariable{
+		{Name: "Env", Default: "dev", Values: []string{"dev", "prod"}, Restricted: true},
+		{Name: "Count", Optional: true, Type: "int", Values: []string{}},
+		{Name: "Other", Default: "x", Values: []string{}},
+	}
+	if !reflect.DeepEqual(parsed[0].Variables, want) {
+		t.Fatalf("got variables %+v, want %+v", parsed[0].Variables, want)
+	}
+
+	filled, err := FillCommand(parsed[0].Command, parsed[0].Variables, map[string]string{"Count": "3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filled != "deploy dev 3 x" {
+		t.Errorf("filled command is %s", filled)
+	}
+	if _, err := FillCommand(parsed[0].Command, parsed[0].Variables, map[string]string{"Env": "staging"}); err == nil {
+		t.Error("expected a value outside of the declared values to be refused")
+	}
+}
+
+func TestConvertKeepsVariables(t *testing.T) {
+	txtPath := writeTestFile(t, "commands.txt", `@name Backup
+@group Files
+tar -czf ${Out?:path=/tmp/backup.tgz} ${Dir[home|etc|*]} && ls ${Out} $${HOME}
+`)
+	dir := filepath.Dir(txtPath)
+	original, err := ParseCommands(txtPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, catalogName := range []string{"commands.yaml", "commands.json"} {
+		t.Run(catalogName, func(t *testing.T) {
+			catalogPath := filepath.Join(dir, catalogName)
+			if _, err := ConvertFile(txtPath, catalogPath); err != nil {
+				t.Fatal(err)
+			}
+
+			catalog, _, _, err := readCatalog(catalogPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := catalog.Commands[0].Command; got != "tar -czf ${Out} ${Dir} && ls ${Out} $${HOME}" {
+				t.Errorf("catalog command is %s, want the definitions moved to the variables", got)
+			}
+			if len(catalog.Commands[0].Variables) != 2 {
+				t.Errorf("catalog has variables %+v, want Out and Dir", catalog.Commands[0].Variables)
+			}
+
+			backPath := filepath.Join(dir, "back-"+catalogName+".txt")
+			if _, err := ConvertFile(catalogPath, backPath); err != nil {
+				t.Fatal(err)
+			}
+			back, err := ParseCommands(backPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if back[0].Command != original[0].Command {
+				t.Errorf("converted back to %s, want %s", back[0].Command, original[0].Command)
+			}
+			if !reflect.DeepEqual(back[0].Variables, original[0].Variables) {
+				t.Errorf("converted back with variables %+v, want %+v", back[0].Variables, original[0].Variables)
+			}
+		})
+	}
+}
+
+func TestRemoveCommandFromCatalogMatchesName(t *testing.T) {
+	catalogPath := writeTestFile(t, "commands.yaml", `commands:
+  - name: Restart web
+    command: systemctl restart ${Service}
+  - name: Restart db
+    command: systemctl restart ${Service}
+`)
+
+	if err := removeCommandFromCatalog(catalogPath, ParsedCommand{Name: "Restart db", Command: "systemctl restart ${Service}"}); err != nil {
+		t.Fatal(err)
+	}
+
+	catalog, _, _, err := readCatalog(catalogPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(catalog.Commands) != 1 || catalog.Commands[0].Name != "Restart web" {
+		t.Errorf("catalog has commands %+v, want only 'Restart web'", catalog.Commands)
+	}
+
+	if err := removeCommandFromCatalog(catalogPath, ParsedCommand{Name: "Restart db", Command: "systemctl restart ${Service}"}); err == nil {
+		t.Error("expected removing a missing command to fail")
+	}
+}
+
+func TestLintCatalogVariables(t *testing.T) {
+	catalogPath := writeTestFile(t, "commands.yaml", `commands:
+  - name: Scale
+    variables:
+      - name: Count
+        type: integer
+      - name: Size
+        type: int
+        default: big
+      - name: Unused
+      - name: Size
+    command: scale ${Count} ${Size:float}
+`)
+
+	got := []string{}
+	for _, diagnostic := range Lint([]string{catalogPath}) {
+		got = append(got, diagnostic.String())
+	}
+
+	for _, want := range []string{
+		"commands.yaml:4:9: error: unknown type 'integer' of variable 'Count'",
+		"commands.yaml:6:9: error: default value of variable 'Size' is invalid",
+		"commands.yaml:9:9: warning: variable 'Unused' is not used in the command",
+		"commands.yaml:10:9: warning: variable 'Size' is declared again",
+		"commands.yaml:11:29: warning: variable 'Size' is defined again as ${Size:float}, the first definition in `variables` is used",
+	} {
+		found := false
+		for _, diagnostic := range got {
+			found = found || strings.Contains(diagnostic, want)
+		}
+		if !found {
+			t.Errorf("missing diagnostic %q in:\n%s", want, strings.Join(got, "\n"))
+		}
+	}
+}
+
+func TestAddCommandToCatalogDeclaresVariables(t *testing.T) {
+	catalogPath := writeTestFile(t, "commands.yaml", "commands: []\n")
+
+	err := addCommandToCatalog(catalogPath, AddCommandInput{CommandName: "Deploy", Command: "deploy ${Env=dev[dev|prod]} ${Env}"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	catalog, _, _, err := readCatalog(catalogPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	added := catalog.Commands[0]
+	if added.Command != "deploy ${Env} ${Env}" {
+		t.Errorf("catalog command is %s, want the definition moved to the variables", added.Command)
+	}
+	want := []CatalogVariable{{Name: "Env", Default: "dev", Values: []string{"dev", "prod"}}}
+	if !reflect.DeepEqual(added.Variables, want) {
+		t.Errorf("catalog has variables %+v, want %+v", added.Variables, want)
+	}
+}
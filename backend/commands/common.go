@@ -36,7 +36,12 @@ type ParsedCommand struct {
 	Group       string           `json:"group"`
 	Description string           `json:"description"`
 	Command     string           `json:"command"`
+	Executor    string           `json:"executor"`
+	Hosts       []string         `json:"hosts"`
+	Timeout     string           `json:"timeout"`
+	TTY         bool             `json:"tty"`
 	Variables   []ParsedVariable `json:"variables"`
+	File        string           `json:"-"` // the commands file or catalog the command comes from
 }
 
 func (p *ParsedVariable) Validate(value string) (bool, error) {
@@ -74,4 +79,3 @@ func (p *ParsedVariable) Validate(value string) (bool, error) {
 
 var valuesRe = regexp.MustCompile(`\$\{(?:(?P<name>.+?)[:=]?)(?P<optional>\?)?(?::(?P<type>.+?)=?)?(?:=(?P<default>.+?))?(?:\[(?P<values>.+?)\])?\}`)
 var PlaceholderRe = regexp.MustCompile(`\$\{(?P<name>.+?)(?:[?:=\[].+?)?\}`)
-var ParsedCommandsList []ParsedCommand
@@ -0,0 +1,45 @@
+package commands
+
+import (
+	"fmt"
+	"path/filepath"
+	"runny-code/common"
+	"strings"
+)
+
+// ConvertFile converts between commands.txt files and YAML or JSON catalogs, the formats follow the extensions
+// Returns the number of converted commands, the comments of commands.txt are not kept
+func ConvertFile(inputPath string, outputPath string) (int, error) {
+	parsedCommands, err := ParseCommands(inputPath)
+	if err != nil {
+		return 0, err
+	}
+
+	if IsCatalogFile(outputPath) {
+		catalog := Catalog{Commands: []CatalogCommand{}}
+		for _, command := range parsedCommands {
+			catalog.Commands = append(catalog.Commands, toCatalogCommand(command))
+		}
+		return len(parsedCommands), writeCatalog(outputPath, catalog)
+	}
+
+	blocks := []string{fmt.Sprintf("# Commands converted from %s", filepath.Base(inputPath))}
+	for _, command := range parsedCommands {
+		block, err := constructCommandLine(AddCommandInput{
+			CommandName: command.Name,
+			GroupName:   command.Group,
+			Description: command.Description,
+			Executor:    command.Executor,
+			Hosts:       strings.Join(command.Hosts, " | "),
+			Timeout:     command.Timeout,
+			TTY:         command.TTY,
+			Command:     inlineVariables(command),
+		})
+		if err != nil {
+			return 0, fmt.Errorf("command '%s': %w", command.Name, err)
+		}
+		blocks = append(blocks, block)
+	}
+
+	return len(parsedCommands), common.WriteFileAtomic(outputPath, []byte(strings.Join(blocks, "\n\n")+"\n"), 0644)
+}
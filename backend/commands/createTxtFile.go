@@ -20,18 +20,35 @@ func CreateCommandsTxtFile(filePath string) error {
 	defer file.Close()
 
 	content := `
-# Commands to run on remote server
+# Commands to run on remote server or locally
+# They can also be kept in commands.yaml or commands.json next to this file, alongside or instead of it
+# Convert between the formats with: runny-code convert commands.txt commands.yaml
 
 # Syntax:
 # @name <command name (Unique and required)>
 # @group <group name (Optional)>
 # @desc <command description (Optional)>
+# @executor <ssh | local (Optional, defaults to the EXECUTOR env)>
+# @host <host name from hosts.json, separate several with | (Optional, defaults to the SSH_HOST env)>
+# @timeout <duration like 30s or 5m (Optional, defaults to the COMMAND_TIMEOUT env)>
+# @tty (Optional, run in an interactive terminal for commands that prompt for input or draw progress bars)
 # <command ...${variable}>
 
 # - The directive must be on one line.
 # - Use only one unique directive per command.
 # - No empty lines or comments between directives and the command.
 
+# Multi-line commands:
+# - End a line with \ to continue the command on the next line.
+# - Or put a whole script between @script and @end lines, kept as is (blank lines, comments and indentation included):
+#   @name Backup
+#   @script
+#   set -e
+#   tar -czf /tmp/backup.tgz ${Path:path=/home}
+#   echo "done in $${PWD}"
+#   @end
+# - Use $${...} to pass a ${...} expansion to the shell instead of declaring a variable.
+
 # Command's variables:
 # ${name}               - A required variable of 'any' type with no default or restricted values.
 # ${Name?}              - An optional variable.
@@ -39,6 +56,7 @@ func CreateCommandsTxtFile(filePath string) error {
 # ${Name=John}          - A default value that should match the specified value type.
 # ${Name[John|Sara]}    - Accepts only the listed values, matching the value type.
 # ${Name[John|Sara|*]}  - Provides auto-completion and allows manual entry of values matching the value type.
+# A variable used several times is defined by its first use, e.g. ${Path:path=/home} then ${Path}.
 
 # Value types:
 # "any"            - Anything (default)
@@ -0,0 +1,41 @@
+package commands
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// Severities of diagnostics
+const (
+	SeverityError   = "error"   // the command can not be parsed or fails when executed
+	SeverityWarning = "warning" // likely a mistake, e.g. ignored directives
+)
+
+// Diagnostic is an error or warning about a commands file, lines and columns start at 1
+// Parse errors are returned as diagnostics too, so they tell where the problem is
+type Diagnostic struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+func (d Diagnostic) Error() string {
+	return fmt.Sprintf("%s:%d:%d: %s", filepath.Base(d.File), d.Line, d.Column, d.Message)
+}
+
+// String is the `file:line:column: severity: message` form printed by `runny-code lint`
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s: %s", d.File, d.Line, d.Column, d.Severity, d.Message)
+}
+
+// HasErrors reports whether any of the diagnostics is an error
+func HasErrors(diagnostics []Diagnostic) bool {
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
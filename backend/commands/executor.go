@@ -0,0 +1,139 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runny-code/common"
+	"runny-code/hosts"
+	"slices"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// Executor runs a filled command and reports its output
+// The command is stopped when the context is done
+type Executor interface {
+	// Execute runs the command and returns the combined stdout and stderr
+	Execute(ctx context.Context, command string, env map[string]string) ([]byte, error)
+
+	// ExecuteStream runs the command and calls outputHandler with each new chunk of output
+	// Each call carries either a stdout or a stderr chunk, calls may come from different goroutines
+	ExecuteStream(ctx context.Context, command string, env map[string]string, outputHandler func(stdout string, stderr string)) error
+}
+
+// Predefined executor names
+const (
+	ExecutorSSH   = "ssh"
+	ExecutorLocal = "local"
+)
+
+var validExecutors = []string{ExecutorSSH, ExecutorLocal}
+
+// IsValidExecutor checks if the name is one of the predefined executors
+func IsValidExecutor(name string) bool {
+	return slices.Contains(validExecutors, name)
+}
+
+// GetExecutor returns the executor for the command, falls back to the `EXECUTOR` env
+// hostName selects one of the command's `@host` entries, empty means the first one
+func GetExecutor(parsedCommand *ParsedCommand, hostName string) (Executor, error) {
+	name := common.Executor_Env
+	if parsedCommand != nil && parsedCommand.Executor != "" {
+		name = parsedCommand.Executor
+	}
+
+	switch name {
+	case ExecutorSSH:
+		host, err := resolveHost(parsedCommand, hostName)
+		if err != nil {
+			return nil, err
+		}
+		return sshExecutor{host: *host}, nil
+	case ExecutorLocal:
+		return localExecutor{}, nil
+	}
+
+	return nil, fmt.Errorf(`unknown executor "%s"`, name)
+}
+
+// CommandEnv returns the environment variables passed to every executed command
+func CommandEnv(parsedCommand *ParsedCommand) map[string]string {
+	return map[string]string{
+		"RUNNY_COMMAND_NAME":  parsedCommand.Name,
+		"RUNNY_COMMAND_GROUP": parsedCommand.Group,
+	}
+}
+
+// WithTimeout returns a context that expires after the command's `@timeout`
+// Falls back to the `COMMAND_TIMEOUT` env, no timeout if neither is set
+func WithTimeout(parent context.Context, parsedCommand *ParsedCommand) (context.Context, context.CancelFunc) {
+	timeout := common.ParseDuration(common.Command_Timeout_Env, 0)
+	if parsedCommand != nil && parsedCommand.Timeout != "" {
+		timeout = common.ParseDuration(parsedCommand.Timeout, timeout)
+	}
+
+	if timeout == 0 {
+		return context.WithCancel(parent)
+	}
+
+	return context.WithTimeout(parent, timeout)
+}
+
+// contextError explains why a command was stopped if its context is done
+func contextError(ctx context.Context, err error) error {
+	if err == nil || ctx.Err() == nil {
+		return err
+	}
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("command timed out: %w", ctx.Err())
+	}
+
+	return fmt.Errorf("command was cancelled: %w", ctx.Err())
+}
+
+// ExitCode extracts the exit status from an execution error
+// Returns 0 for a nil error and -1 if the command did not exit on its own
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	var sshExitErr *ssh.ExitError
+	if errors.As(err, &sshExitErr) {
+		return sshExitErr.ExitStatus()
+	}
+
+	var localExitErr *exec.ExitError
+	if errors.As(err, &localExitErr) {
+		return localExitErr.ExitCode()
+	}
+
+	return -1
+}
+
+// resolveHost picks the host to run the command on
+// Commands without `@host` may only run on the default host
+func resolveHost(parsedCommand *ParsedCommand, hostName string) (*hosts.Host, error) {
+	allowedHosts := []string{hosts.DefaultHostName}
+	if parsedCommand != nil && len(parsedCommand.Hosts) > 0 {
+		allowedHosts = parsedCommand.Hosts
+	}
+
+	if hostName == "" {
+		hostName = allowedHosts[0]
+	}
+
+	if !slices.Contains(allowedHosts, hostName) {
+		return nil, fmt.Errorf(`host "%s" is not allowed for this command`, hostName)
+	}
+
+	host := hosts.FindHost(hostName)
+	if host == nil {
+		return nil, fmt.Errorf(`host "%s" is not defined`, hostName)
+	}
+
+	return host, nil
+}
@@ -0,0 +1,126 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var ScriptDirectiveRe = regexp.MustCompile(`^\s*@script\s*$`)
+var EndDirectiveRe = regexp.MustCompile(`^\s*@end\s*$`)
+
+// fileEntry is a line of the commands file, or all the lines of a multi-line command
+type fileEntry struct {
+	rawLines  []string // as written in the file
+	line      string   // trimmed, empty for blank lines
+	command   string   // the command body, set for commands only
+	lineIndex int      // of the first raw line
+
+	bodyLineIndex int   // of the first line of the command body
+	bodyIndents   []int // indentation of each body line, trimmed from the body of continued lines
+}
+
+func (e *fileEntry) isCommand() bool {
+	return e.command != ""
+}
+
+// readFileEntries splits the commands file into entries, joining multi-line commands:
+//   - lines ending with `\` continue on the next line, the command keeps the `\` and line breaks for the shell
+//   - lines between `@script` and `@end` are one command as is, blank lines, comments and indentation included
+func readFileEntries(filePath string) ([]fileEntry, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	lines := []string{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	entries := []fileEntry{}
+	for i := 0; i < len(lines); i++ {
+		start := i
+		line := strings.TrimSpace(lines[i])
+
+		switch {
+		case ScriptDirectiveRe.MatchString(line):
+			end := start + 1
+			for end < len(lines) && !EndDirectiveRe.MatchString(lines[end]) {
+				end++
+			}
+			if end == len(lines) {
+				return nil, Diagnostic{File: filePath, Line: start + 1, Column: indentOf(lines[start]) + 1, Severity: SeverityError, Message: "`@script` is not closed with `@end`"}
+			}
+
+			body := strings.Join(lines[start+1:end], "\n")
+			if strings.TrimSpace(body) == "" {
+				return nil, Diagnostic{File: filePath, Line: start + 1, Column: indentOf(lines[start]) + 1, Severity: SeverityError, Message: "`@script` block is empty"}
+			}
+			entries = append(entries, fileEntry{rawLines: lines[start : end+1], line: line, command: body, lineIndex: start, bodyLineIndex: start + 1, bodyIndents: make([]int, end-start-1)})
+			i = end
+
+		case line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "@"):
+			entries = append(entries, fileEntry{rawLines: lines[start : start+1], line: line, lineIndex: start})
+
+		default:
+			body := []string{line}
+			indents := []int{indentOf(lines[i])}
+			for hasLineContinuation(body[len(body)-1]) && i+1 < len(lines) {
+				i++
+				body = append(body, strings.TrimSpace(lines[i]))
+				indents = append(indents, indentOf(lines[i]))
+			}
+			entries = append(entries, fileEntry{rawLines: lines[start : i+1], line: line, command: strings.Join(body, "\n"), lineIndex: start, bodyLineIndex: start, bodyIndents: indents})
+		}
+	}
+
+	return entries, nil
+}
+
+// bodyPosition returns the line and column in the file of an offset in the command body
+func (e *fileEntry) bodyPosition(offset int) (int, int) {
+	lineIndex, column := offsetPosition(e.command, offset)
+	return e.bodyLineIndex + lineIndex + 1, e.bodyIndents[lineIndex] + column + 1
+}
+
+// offsetPosition returns the line index and the column index of an offset in a multi-line string
+func offsetPosition(text string, offset int) (int, int) {
+	lineIndex := strings.Count(text[:offset], "\n")
+	return lineIndex, offset - (strings.LastIndex(text[:offset], "\n") + 1)
+}
+
+func indentOf(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " \t"))
+}
+
+// hasLineContinuation reports whether the line ends with an unescaped `\`
+func hasLineContinuation(line string) bool {
+	trailing := len(line) - len(strings.TrimRight(line, `\`))
+	return trailing%2 == 1
+}
+
+// formatCommandBody writes a command for the commands file, multi-line commands become a `@script` block
+func formatCommandBody(command string) (string, error) {
+	command = strings.ReplaceAll(command, "\r\n", "\n")
+	if hasLineContinuation(command) {
+		return "", fmt.Errorf("a command can not end with `\\`")
+	}
+	if !strings.Contains(command, "\n") {
+		return command, nil
+	}
+
+	for _, line := range strings.Split(command, "\n") {
+		if EndDirectiveRe.MatchString(line) {
+			return "", fmt.Errorf("a multi-line command can not contain a line with only `@end`")
+		}
+	}
+	return "@script\n" + command + "\n@end", nil
+}
@@ -0,0 +1,44 @@
+package commands
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReadFileEntries(t *testing.T) {
+	tests := []struct {
+		name         string
+		content      string
+		wantCommands []string
+		wantErr      bool
+	}{
+		{"single line", "@name A\necho a\n", []string{"echo a"}, false},
+		{"line continuation", "echo a \\\n  && echo b\n", []string{"echo a \\\n&& echo b"}, false},
+		{"escaped backslash does not continue", "echo a\\\\\necho b\n", []string{"echo a\\\\", "echo b"}, false},
+		{"continuation at the end of the file", "echo a \\\n", []string{"echo a \\"}, false},
+		{"comments and blank lines are not commands", "# echo a\n\n@desc echo b\necho c\n", []string{"echo c"}, false},
+		{"script block is kept as is", "@script\n  if true; then\n\n    echo a # comment\n  fi\n@end\n", []string{"  if true; then\n\n    echo a # comment\n  fi"}, false},
+		{"script block with a continued line", "@script\necho a \\\n@end\necho b\n", []string{"echo a \\", "echo b"}, false},
+		{"unclosed script block", "@script\necho a\n", nil, true},
+		{"empty script block", "@script\n\n@end\n", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			entries, err := readFileEntries(writeTestFile(t, "commands.txt", test.content))
+			if (err != nil) != test.wantErr {
+				t.Fatalf("got error %v, want an error: %t", err, test.wantErr)
+			}
+
+			commands := []string{}
+			for _, entry := range entries {
+				if entry.isCommand() {
+					commands = append(commands, entry.command)
+				}
+			}
+			if err == nil && !reflect.DeepEqual(commands, test.wantCommands) {
+				t.Errorf("got commands %q, want %q", commands, test.wantCommands)
+			}
+		})
+	}
+}
@@ -6,13 +6,23 @@ import (
 )
 
 // FillCommand replaces placeholders with values and validates input
+// Escaped `$${...}` placeholders are passed to the shell as `${...}`
 func FillCommand(cmd string, variables []ParsedVariable, argsMap map[string]string) (string, error) {
-	matches := PlaceholderRe.FindAllStringSubmatch(cmd, -1)
+	matches := PlaceholderRe.FindAllStringSubmatchIndex(cmd, -1)
 
-	newCmd := cmd
+	newCmd := strings.Builder{}
+	lastIndex := 0
 	for _, match := range matches {
-		placeholder := match[0]
-		argName := match[1]
+		start, end := match[0], match[1]
+
+		if isEscapedPlaceholder(cmd, start) {
+			newCmd.WriteString(cmd[lastIndex : start-1])
+			newCmd.WriteString(cmd[start:end])
+			lastIndex = end
+			continue
+		}
+
+		argName := cmd[match[2]:match[3]]
 
 		variable := findVariable(&variables, argName)
 		if variable == nil {
@@ -35,8 +45,11 @@ func FillCommand(cmd string, variables []ParsedVariable, argsMap map[string]stri
 
 		replacement := wrapInQuotes(argValue)
 
-		newCmd = strings.ReplaceAll(newCmd, placeholder, replacement)
+		newCmd.WriteString(cmd[lastIndex:start])
+		newCmd.WriteString(replacement)
+		lastIndex = end
 	}
+	newCmd.WriteString(cmd[lastIndex:])
 
-	return newCmd, nil
+	return newCmd.String(), nil
 }
@@ -0,0 +1,37 @@
+package commands
+
+import "testing"
+
+func TestFillCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		args    map[string]string
+		want    string
+		wantErr bool
+	}{
+		{"plain value", "echo ${A}", map[string]string{"A": "plain"}, "echo plain", false},
+		{"value with spaces", "echo ${A}", map[string]string{"A": "hello world"}, `echo "hello world"`, false},
+		{"value with double quotes", "echo ${A}", map[string]string{"A": `say "hi" now`}, `echo 'say "hi" now'`, false},
+		{"value with both quotes", "echo ${A}", map[string]string{"A": `it's "x" y`}, `echo "it's \"x\" y"`, false},
+		{"escaped placeholder", "echo $${HOME} ${A}", map[string]string{"A": "x"}, "echo ${HOME} x", false},
+		{"escaped placeholder of a variable", "echo ${A} $${A}", map[string]string{"A": "x"}, "echo x ${A}", false},
+		{"only one dollar is removed", "echo $$${A}", map[string]string{"A": "x"}, "echo $${A}", false},
+		{"default value", "echo ${A=fallback}", nil, "echo fallback", false},
+		{"value outside of the list", "echo ${A[dev|prod]}", map[string]string{"A": "test"}, "", true},
+		{"invalid type", "echo ${A:int}", map[string]string{"A": "ten"}, "", true},
+		{"missing required value", "echo ${A}", nil, "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := FillCommand(test.command, ParseVariables(test.command), test.args)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("got error %v, want an error: %t", err, test.wantErr)
+			}
+			if got != test.want {
+				t.Errorf("got %s, want %s", got, test.want)
+			}
+		})
+	}
+}
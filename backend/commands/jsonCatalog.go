@@ -0,0 +1,109 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// readJSONCatalog reads a JSON catalog with encoding/json, YAML does not read every JSON document the same way
+// (surrogate pairs, duplicate keys). The positions of the values are returned as a YAML document to share the lookups
+func readJSONCatalog(filePath string, fileBytes []byte) (Catalog, yaml.Node, error) {
+	catalog := Catalog{}
+	err := json.Unmarshal(fileBytes, &catalog)
+	if err != nil {
+		return Catalog{}, yaml.Node{}, jsonDiagnostic(filePath, fileBytes, err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(fileBytes))
+	node, err := jsonNode(decoder, fileBytes)
+	if err != nil {
+		return Catalog{}, yaml.Node{}, jsonDiagnostic(filePath, fileBytes, err)
+	}
+	return catalog, yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{node}}, nil
+}
+
+// jsonNode reads the next value of the decoder with the positions of its values
+// Like encoding/json, the last of duplicate keys is the one used
+func jsonNode(decoder *json.Decoder, fileBytes []byte) (*yaml.Node, error) {
+	line, column := jsonPosition(fileBytes, decoder.InputOffset())
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	node := &yaml.Node{Kind: yaml.ScalarNode, Line: line, Column: column}
+	switch token := token.(type) {
+	case json.Delim:
+		if token == '{' {
+			node.Kind = yaml.MappingNode
+			for decoder.More() {
+				keyLine, keyColumn := jsonPosition(fileBytes, decoder.InputOffset())
+				key, err := decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+				value, err := jsonNode(decoder, fileBytes)
+				if err != nil {
+					return nil, err
+				}
+
+				keyNode := &yaml.Node{Kind: yaml.ScalarNode, Style: yaml.DoubleQuotedStyle, Value: fmt.Sprint(key), Line: keyLine, Column: keyColumn}
+				index := slices.IndexFunc(node.Content, func(existing *yaml.Node) bool { return existing.Value == keyNode.Value })
+				if index%2 == 0 {
+					node.Content[index], node.Content[index+1] = keyNode, value
+				} else {
+					node.Content = append(node.Content, keyNode, value)
+				}
+			}
+		} else {
+			node.Kind = yaml.SequenceNode
+			for decoder.More() {
+				item, err := jsonNode(decoder, fileBytes)
+				if err != nil {
+					return nil, err
+				}
+				node.Content = append(node.Content, item)
+			}
+		}
+		// the closing delimiter
+		if _, err := decoder.Token(); err != nil {
+			return nil, err
+		}
+	case string:
+		node.Style = yaml.DoubleQuotedStyle
+		node.Value = token
+	default:
+		node.Value = fmt.Sprint(token)
+	}
+	return node, nil
+}
+
+// jsonPosition returns the line and column of the value starting after the offset, the decoder leaves separators unread
+func jsonPosition(fileBytes []byte, offset int64) (int, int) {
+	start := int(offset)
+	for start < len(fileBytes) && bytes.IndexByte([]byte(" \t\r\n,:"), fileBytes[start]) != -1 {
+		start++
+	}
+	lineIndex, column := offsetPosition(string(fileBytes), start)
+	return lineIndex + 1, column + 1
+}
+
+func jsonDiagnostic(filePath string, fileBytes []byte, err error) Diagnostic {
+	// the offset of the errors is after the byte where they occurred
+	offset := int64(0)
+	syntaxError := &json.SyntaxError{}
+	typeError := &json.UnmarshalTypeError{}
+	if errors.As(err, &syntaxError) {
+		offset = syntaxError.Offset
+	} else if errors.As(err, &typeError) {
+		offset = typeError.Offset
+	}
+
+	lineIndex, column := offsetPosition(string(fileBytes), int(max(offset-1, 0)))
+	return Diagnostic{File: filePath, Line: lineIndex + 1, Column: column + 1, Severity: SeverityError, Message: err.Error()}
+}
@@ -0,0 +1,57 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadJSONCatalog(t *testing.T) {
+	catalogPath := writeTestFile(t, "commands.json", `{
+  "commands": [
+    {
+      "name": "Greet",
+      "description": "Says hi \ud83d\ude00",
+      "command": "echo \"\ud83d\ude00 ${Name}\""
+    },
+    {
+      "name": "First",
+      "name": "Second",
+      "command": "echo ${Other:integer}"
+    }
+  ]
+}
+`)
+
+	catalog, _, _, err := readCatalog(catalogPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := catalog.Commands[0].Description; got != "Says hi 😀" {
+		t.Errorf("description is %q, want the surrogate pair read as one character", got)
+	}
+	if got := catalog.Commands[0].Command; got != `echo "😀 ${Name}"` {
+		t.Errorf("command is %q", got)
+	}
+	if got := catalog.Commands[1].Name; got != "Second" {
+		t.Errorf("name is %q, want the last of the duplicate keys", got)
+	}
+
+	diagnostics := []string{}
+	for _, diagnostic := range Lint([]string{catalogPath}) {
+		diagnostics = append(diagnostics, diagnostic.String())
+	}
+	want := "commands.json:11:24: error: unknown type 'integer' of variable 'Other'"
+	if !strings.Contains(strings.Join(diagnostics, "\n"), want) {
+		t.Errorf("missing diagnostic %q in:\n%s", want, strings.Join(diagnostics, "\n"))
+	}
+}
+
+func TestReadJSONCatalogReportsSyntaxErrors(t *testing.T) {
+	catalogPath := writeTestFile(t, "commands.json", "{\n  \"commands\": [\n    {\"name\": \"A\",}\n  ]\n}\n")
+
+	_, _, _, err := readCatalog(catalogPath)
+	diagnostic, ok := err.(Diagnostic)
+	if !ok || diagnostic.Line != 3 {
+		t.Errorf("got error %v, want a diagnostic on line 3", err)
+	}
+}
@@ -0,0 +1,317 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// directiveKeywordRe extracts the keyword of a directive line, e.g. `@name` of `@name List files`
+var directiveKeywordRe = regexp.MustCompile(`^@(\S*)`)
+
+// directiveKeywords are the known directives, `@desc` is short for `@description`
+var directiveKeywords = []string{"name", "group", "description", "desc", "executor", "host", "timeout", "tty"}
+
+// locatedCommand is a parsed command with its position in its file
+type locatedCommand struct {
+	ParsedCommand
+	line     int // of the name, or of the command when it has none
+	column   int
+	position func(offset int) (int, int) // in the file, of an offset in the command
+	declared []string                    // variables declared in the `variables` of a catalog
+}
+
+// Lint parses the commands files and checks them, diagnostics are sorted by file, line and column
+func Lint(files []string) []Diagnostic {
+	diagnostics := []Diagnostic{}
+	definitions := map[string]locatedCommand{} // by name, to report duplicates
+
+	for _, filePath := range files {
+		located, fileDiagnostics := locateCommands(filePath)
+		for _, command := range located {
+			fileDiagnostics = append(fileDiagnostics, lintCommand(command, definitions)...)
+		}
+
+		slices.SortStableFunc(fileDiagnostics, func(a, b Diagnostic) int {
+			if a.Line != b.Line {
+				return a.Line - b.Line
+			}
+			return a.Column - b.Column
+		})
+		diagnostics = append(diagnostics, fileDiagnostics...)
+	}
+
+	return diagnostics
+}
+
+// PrintFindings lints the commands files and prints the diagnostics
+// Only failing to parse a file prevents the commands from loading, at startup as on reload, the findings are warnings
+func PrintFindings() {
+	files, err := CommandsFiles()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to lint the commands files: %s\n", err)
+		return
+	}
+
+	for _, diagnostic := range Lint(files) {
+		fmt.Fprintln(os.Stderr, diagnostic.String())
+	}
+}
+
+// locateCommands parses a commands file, parse errors are returned as diagnostics
+func locateCommands(filePath string) ([]locatedCommand, []Diagnostic) {
+	parsedCommands, err := ParseCommands(filePath)
+	if err != nil {
+		return nil, []Diagnostic{asDiagnostic(filePath, err)}
+	}
+
+	located := []locatedCommand{}
+
+	if IsCatalogFile(filePath) {
+		catalog, positions, lines, err := readCatalog(filePath)
+		if err != nil {
+			return nil, []Diagnostic{asDiagnostic(filePath, err)}
+		}
+
+		diagnostics := []Diagnostic{}
+		for i, command := range parsedCommands {
+			position := positions[i]
+			line, column := position.line, position.column
+			if position.nameNode != nil {
+				line, column = position.nameNode.Line, position.nameNode.Column
+			}
+
+			declared := []string{}
+			for _, variable := range catalog.Commands[i].Variables {
+				declared = append(declared, strings.TrimSpace(variable.Name))
+			}
+
+			located = append(located, locatedCommand{ParsedCommand: command, line: line, column: column, declared: declared, position: func(offset int) (int, int) {
+				return position.commandPosition(lines, command.Command, offset)
+			}})
+			diagnostics = append(diagnostics, lintCatalogVariables(filePath, catalog.Commands[i], position)...)
+		}
+		return located, diagnostics
+	}
+
+	entries, err := readFileEntries(filePath)
+	if err != nil {
+		return nil, []Diagnostic{asDiagnostic(filePath, err)}
+	}
+
+	commandIndex := 0
+	for index, entry := range entries {
+		if !entry.isCommand() {
+			continue
+		}
+
+		line, column := entry.lineIndex+1, indentOf(entry.rawLines[0])+1
+		for i := index - 1; i >= 0 && isDirective(entries[i]); i-- {
+			if directiveKeyword(entries[i].line) == "name" {
+				line, column = entries[i].lineIndex+1, indentOf(entries[i].rawLines[0])+1
+			}
+		}
+
+		located = append(located, locatedCommand{ParsedCommand: parsedCommands[commandIndex], line: line, column: column, position: entry.bodyPosition})
+		commandIndex++
+	}
+
+	return located, lintDirectives(filePath, entries)
+}
+
+// lintDirectives warns about directives that are ignored: unknown, repeated or not attached to a command
+func lintDirectives(filePath string, entries []fileEntry) []Diagnostic {
+	diagnostics := []Diagnostic{}
+	warn := func(entry fileEntry, message string) {
+		diagnostics = append(diagnostics, Diagnostic{File: filePath, Line: entry.lineIndex + 1, Column: indentOf(entry.rawLines[0]) + 1, Severity: SeverityWarning, Message: message})
+	}
+
+	for start := 0; start < len(entries); start++ {
+		if !isDirective(entries[start]) {
+			continue
+		}
+
+		end := start
+		for end < len(entries) && isDirective(entries[end]) {
+			end++
+		}
+
+		seen := map[string]bool{}
+		for _, entry := range entries[start:end] {
+			keyword := directiveKeyword(entry.line)
+			if keyword == "desc" {
+				keyword = "description"
+			}
+
+			switch {
+			case keyword == "end":
+				warn(entry, "`@end` without `@script`")
+			case !slices.Contains(directiveKeywords, keyword):
+				warn(entry, fmt.Sprintf("unknown directive `@%s`, it is ignored", keyword))
+			case seen[keyword]:
+				warn(entry, fmt.Sprintf("`@%s` is repeated, the first one is used", keyword))
+			}
+			seen[keyword] = true
+		}
+
+		if end < len(entries) && entries[end].isCommand() {
+			start = end
+			continue
+		}
+
+		// blank lines and comments end the directives of a command
+		next := end
+		for next < len(entries) && !entries[next].isCommand() && !isDirective(entries[next]) {
+			next++
+		}
+		if next < len(entries) && entries[next].isCommand() {
+			warn(entries[start], fmt.Sprintf("directives are separated from the command on line %d by a blank line or comment, they are ignored", entries[next].lineIndex+1))
+		} else {
+			warn(entries[start], "directives are not followed by a command, they are ignored")
+		}
+		start = end - 1
+	}
+
+	return diagnostics
+}
+
+// lintCatalogVariables checks the `variables` of a catalog command
+func lintCatalogVariables(filePath string, command CatalogCommand, position catalogPosition) []Diagnostic {
+	diagnostics := []Diagnostic{}
+	seen := map[string]bool{}
+	used := map[string]bool{}
+	for _, match := range findPlaceholders(PlaceholderRe, command.Command) {
+		used[command.Command[match[2]:match[3]]] = true
+	}
+
+	for i, catalogVariable := range command.Variables {
+		line, column := position.variablePosition(i)
+		report := func(severity string, message string) {
+			diagnostics = append(diagnostics, Diagnostic{File: filePath, Line: line, Column: column, Severity: severity, Message: message})
+		}
+
+		name := strings.TrimSpace(catalogVariable.Name)
+		switch {
+		case name == "":
+			report(SeverityWarning, "variable has no name, it is ignored")
+			continue
+		case seen[name]:
+			report(SeverityWarning, fmt.Sprintf("variable '%s' is declared again, the first declaration is used", name))
+			continue
+		case !used[name]:
+			report(SeverityWarning, fmt.Sprintf("variable '%s' is not used in the command", name))
+		}
+		seen[name] = true
+
+		rawType := strings.TrimSpace(catalogVariable.Type)
+		if rawType != "" && !slices.Contains(validTypes, rawType) {
+			report(SeverityError, fmt.Sprintf("unknown type '%s' of variable '%s', expected one of %s", rawType, name, strings.Join(validTypes, ", ")))
+		}
+
+		variable := declaredVariables([]CatalogVariable{catalogVariable})[0]
+		if variable.Default != "" {
+			if valid, err := variable.Validate(variable.Default); !valid {
+				report(SeverityError, fmt.Sprintf("default value of variable '%s' is invalid: %s", name, err))
+			}
+		}
+	}
+
+	return diagnostics
+}
+
+// lintCommand checks the name, directives and variables of a command
+func lintCommand(command locatedCommand, definitions map[string]locatedCommand) []Diagnostic {
+	diagnostics := []Diagnostic{}
+	report := func(line int, column int, severity string, message string) {
+		diagnostics = append(diagnostics, Diagnostic{File: command.File, Line: line, Column: column, Severity: severity, Message: message})
+	}
+
+	if command.Name == "" {
+		report(command.line, command.column, SeverityWarning, "command has no name")
+	} else if first, found := definitions[command.Name]; found {
+		report(command.line, command.column, SeverityError, fmt.Sprintf("duplicate command name '%s', first defined at %s:%d", command.Name, filepath.Base(first.File), first.line))
+	} else {
+		definitions[command.Name] = command
+	}
+
+	if command.Executor != "" && !IsValidExecutor(command.Executor) {
+		report(command.line, command.column, SeverityError, fmt.Sprintf("unknown executor '%s', expected one of %s", command.Executor, strings.Join(validExecutors, ", ")))
+	}
+	if duration, err := time.ParseDuration(command.Timeout); command.Timeout != "" && (err != nil || duration <= 0) {
+		report(command.line, command.column, SeverityWarning, fmt.Sprintf("invalid timeout '%s', the default timeout is used", command.Timeout))
+	}
+
+	// each placeholder declares its variable, a variable used several times is defined by its first use
+	typeIndex := valuesRe.SubexpIndex("type")
+	declared := map[string]string{}
+	for _, name := range command.declared {
+		declared[name] = "in `variables`"
+	}
+	for _, match := range findPlaceholders(valuesRe, command.Command) {
+		line, column := command.position(match[0])
+		placeholder := command.Command[match[0]:match[1]]
+		variable := ParseVariables(placeholder)[0]
+
+		if match[2*typeIndex] >= 0 {
+			rawType := command.Command[match[2*typeIndex]:match[2*typeIndex+1]]
+			if !slices.Contains(validTypes, rawType) {
+				message := fmt.Sprintf("unknown type '%s' of variable '%s', expected one of %s", rawType, variable.Name, strings.Join(validTypes, ", "))
+				if strings.ContainsAny(rawType[:1], "-+?=") {
+					message += " (use $${...} to pass a shell expansion like ${var:-default} to the shell)"
+				}
+				report(line, column, SeverityError, message)
+			}
+		}
+
+		if first, found := declared[variable.Name]; found {
+			if first != placeholder && placeholder != "${"+variable.Name+"}" {
+				report(line, column, SeverityWarning, fmt.Sprintf("variable '%s' is defined again as %s, the first definition %s is used", variable.Name, placeholder, first))
+			}
+			continue
+		}
+		declared[variable.Name] = placeholder
+
+		if variable.Default != "" {
+			if valid, err := variable.Validate(variable.Default); !valid {
+				report(line, column, SeverityError, fmt.Sprintf("default value of variable '%s' is invalid: %s", variable.Name, err))
+			}
+		}
+	}
+
+	// placeholders that do not parse as a variable fail when the command is executed
+	for _, match := range findPlaceholders(PlaceholderRe, command.Command) {
+		name := command.Command[match[2]:match[3]]
+		if findVariable(&command.Variables, name) == nil {
+			line, column := command.position(match[0])
+			report(line, column, SeverityError, fmt.Sprintf("placeholder %s does not define a variable named '%s'", command.Command[match[0]:match[1]], name))
+		}
+	}
+
+	return diagnostics
+}
+
+func isDirective(entry fileEntry) bool {
+	return !entry.isCommand() && strings.HasPrefix(entry.line, "@")
+}
+
+func directiveKeyword(line string) string {
+	match := directiveKeywordRe.FindStringSubmatch(line)
+	if match == nil {
+		return ""
+	}
+	return match[1]
+}
+
+// asDiagnostic keeps the position of parse errors, other errors (e.g. a missing file) are reported on the first line
+func asDiagnostic(filePath string, err error) Diagnostic {
+	var diagnostic Diagnostic
+	if errors.As(err, &diagnostic) {
+		return diagnostic
+	}
+	return Diagnostic{File: filePath, Line: 1, Column: 1, Severity: SeverityError, Message: err.Error()}
+}
@@ -0,0 +1,142 @@
+package commands
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLintCommandsFile(t *testing.T) {
+	type want struct {
+		line     int
+		column   int
+		severity string
+		message  string // contained in the message
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		want    []want
+	}{
+		{
+			name:    "valid file",
+			content: "@name Hello\n@group Greetings\necho hello ${Name?:non-numeric=world}\n",
+			want:    nil,
+		},
+		{
+			name:    "unknown type",
+			content: "@name Scale\nscale ${Count:integer}\n",
+			want:    []want{{2, 7, SeverityError, "unknown type 'integer' of variable 'Count'"}},
+		},
+		{
+			name:    "shell expansion",
+			content: "@name Greet\necho ${NAME:-world}\n",
+			want:    []want{{2, 6, SeverityError, "use $${...} to pass a shell expansion"}},
+		},
+		{
+			name:    "duplicate name",
+			content: "@name Hello\necho hello\n\n  @name Hello\n  echo hi\n",
+			want:    []want{{4, 3, SeverityError, "duplicate command name 'Hello', first defined at commands.txt:1"}},
+		},
+		{
+			name:    "invalid default",
+			content: "@name Scale\nscale \\\n  ${Count:int=many}\n",
+			want:    []want{{3, 3, SeverityError, "default value of variable 'Count' is invalid"}},
+		},
+		{
+			name:    "default outside of the values",
+			content: "@name Deploy\ndeploy ${Env=test[dev|prod]}\n",
+			want:    []want{{2, 8, SeverityError, "default value of variable 'Env' is invalid"}},
+		},
+		{
+			name:    "undefined placeholder",
+			content: "@name List\nls ${Dir[home}\n",
+			want:    []want{{2, 4, SeverityError, "placeholder ${Dir[home} does not define a variable named 'Dir'"}},
+		},
+		{
+			name:    "variable defined again",
+			content: "@name Copy\ncp ${Path:path} ${Path:any}\n",
+			want:    []want{{2, 17, SeverityWarning, "variable 'Path' is defined again as ${Path:any}"}},
+		},
+		{
+			name:    "directives separated by a blank line",
+			content: "@name Hello\n@group Greetings\n\necho hello\n",
+			want: []want{
+				{1, 1, SeverityWarning, "directives are separated from the command on line 4"},
+				{4, 1, SeverityWarning, "command has no name"},
+			},
+		},
+		{
+			name:    "directives separated by a comment",
+			content: "@name Hello\n# says hello\necho hello\n",
+			want: []want{
+				{1, 1, SeverityWarning, "directives are separated from the command on line 3"},
+				{3, 1, SeverityWarning, "command has no name"},
+			},
+		},
+		{
+			name:    "directives without a command",
+			content: "@name Hello\necho hello\n\n@name Orphan\n",
+			want:    []want{{4, 1, SeverityWarning, "directives are not followed by a command"}},
+		},
+		{
+			name:    "unknown and repeated directives",
+			content: "@name Hello\n@grup Greetings\n@name Hi\necho hello\n",
+			want: []want{
+				{2, 1, SeverityWarning, "unknown directive `@grup`"},
+				{3, 1, SeverityWarning, "`@name` is repeated"},
+			},
+		},
+		{
+			name:    "unknown executor and invalid timeout",
+			content: "@name Hello\n@executor docker\n@timeout soon\necho hello\n",
+			want: []want{
+				{1, 1, SeverityError, "unknown executor 'docker'"},
+				{1, 1, SeverityWarning, "invalid timeout 'soon'"},
+			},
+		},
+		{
+			name:    "unclosed script",
+			content: "@name Hello\n  @script\necho hello\n",
+			want:    []want{{2, 3, SeverityError, "`@script` is not closed with `@end`"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filePath := writeTestFile(t, "commands.txt", test.content)
+			diagnostics := Lint([]string{filePath})
+
+			got := []string{}
+			for _, diagnostic := range diagnostics {
+				got = append(got, diagnostic.String())
+			}
+			if len(diagnostics) != len(test.want) {
+				t.Fatalf("got diagnostics:\n%s\nwant %d", strings.Join(got, "\n"), len(test.want))
+			}
+
+			for i, want := range test.want {
+				diagnostic := diagnostics[i]
+				if diagnostic.File != filePath || diagnostic.Line != want.line || diagnostic.Column != want.column ||
+					diagnostic.Severity != want.severity || !strings.Contains(diagnostic.Message, want.message) {
+					t.Errorf("got %s, want %s:%d:%d: %s: %s", diagnostic, filepath.Base(filePath), want.line, want.column, want.severity, want.message)
+				}
+			}
+		})
+	}
+}
+
+func TestLintReportsDuplicatesAcrossFiles(t *testing.T) {
+	txtPath := writeTestFile(t, "commands.txt", "@name Hello\necho hello\n")
+	catalogPath := writeTestFile(t, "commands.yaml", "commands:\n  - name: Hello\n    command: echo hi\n")
+
+	diagnostics := Lint([]string{txtPath, catalogPath})
+	if len(diagnostics) != 1 {
+		t.Fatalf("got diagnostics %v, want one", diagnostics)
+	}
+	if got := diagnostics[0]; got.File != catalogPath || got.Line != 2 || got.Column != 11 || got.Severity != SeverityError ||
+		!strings.Contains(got.Message, "first defined at commands.txt:1") {
+		t.Errorf("got %s, want the duplicate reported on the catalog", got)
+	}
+}
@@ -0,0 +1,98 @@
+package commands
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"runny-code/common"
+	"time"
+)
+
+// localExecutor runs commands on the same machine using `LOCAL_SHELL`
+type localExecutor struct{}
+
+// createLocalCommand returns the command and its process group, whose markExited must be called once the command is waited for
+func createLocalCommand(ctx context.Context, command string, env map[string]string) (*exec.Cmd, *processGroup) {
+	cmd := exec.CommandContext(ctx, common.Local_Shell_Env, "-c", command)
+
+	// Ask the process to stop when the context is done, kill it if it is still running after the delay
+	group := newProcessGroup(cmd)
+	cmd.Cancel = func() error {
+		return group.terminate(5 * time.Second)
+	}
+	cmd.WaitDelay = 5 * time.Second
+
+	cmd.Env = os.Environ()
+	for key, value := range env {
+		cmd.Env = append(cmd.Env, key+"="+value)
+	}
+
+	return cmd, group
+}
+
+func (localExecutor) Execute(ctx context.Context, command string, env map[string]string) ([]byte, error) {
+	cmd, group := createLocalCommand(ctx, command, env)
+	output, err := cmd.CombinedOutput()
+	group.markExited()
+	return output, contextError(ctx, err)
+}
+
+func (localExecutor) ExecuteStream(ctx context.Context, command string, env map[string]string, outputHandler func(stdout string, stderr string)) error {
+	cmd, group := createLocalCommand(ctx, command, env)
+
+	// Get stdout and stderr pipes
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+
+	// Start the command
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	// Stream stdout and stderr concurrently
+	doneChan := make(chan error, 2)
+	go streamOutput(stdout, outputHandler, doneChan, false)
+	go streamOutput(stderr, outputHandler, doneChan, true)
+
+	// Wait for both streams to finish before waiting, pipes are closed by Wait
+	for range 2 {
+		<-doneChan
+	}
+
+	err = cmd.Wait()
+	group.markExited()
+	return contextError(ctx, err)
+}
+
+func (localExecutor) ExecuteInteractive(ctx context.Context, command string, env map[string]string, terminal Terminal) error {
+	cmd, group := createLocalCommand(ctx, command, env)
+	cmd.Env = append(cmd.Env, "TERM=xterm-256color")
+
+	size := terminal.Size.orDefault()
+	ptmx, err := startPty(cmd, size)
+	if err != nil {
+		return err
+	}
+	defer ptmx.Close()
+
+	go io.Copy(ptmx, terminal.Stdin)
+	go func() {
+		for size := range terminal.Resize {
+			resizePty(ptmx, size.orDefault())
+		}
+	}()
+
+	// reading the pty fails once the process and its children exit
+	io.Copy(terminal.Output, ptmx)
+
+	err = cmd.Wait()
+	group.markExited()
+	return contextError(ctx, err)
+}
@@ -0,0 +1,106 @@
+//go:build unix
+
+package commands
+
+import (
+	"context"
+	"errors"
+	"runny-code/common"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func useLocalShell(t *testing.T) {
+	previous := common.Local_Shell_Env
+	common.Local_Shell_Env = "/bin/sh"
+	t.Cleanup(func() { common.Local_Shell_Env = previous })
+}
+
+func TestLocalExecutorExecute(t *testing.T) {
+	useLocalShell(t)
+	env := CommandEnv(&ParsedCommand{Name: "Deploy", Group: "web"})
+
+	tests := []struct {
+		name         string
+		command      string
+		want         string
+		wantExitCode int
+	}{
+		{"stdout and stderr are combined", "echo out; echo err >&2", "out\nerr\n", 0},
+		{"exit code", "echo failing; exit 3", "failing\n", 3},
+		{"command env", `echo "$RUNNY_COMMAND_NAME $RUNNY_COMMAND_GROUP"`, "Deploy web\n", 0},
+		{"unknown command", "runny-code-missing-command 2>/dev/null", "", 127},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output, err := localExecutor{}.Execute(context.Background(), test.command, env)
+			if string(output) != test.want {
+				t.Errorf("got output %q, want %q", output, test.want)
+			}
+			if code := ExitCode(err); code != test.wantExitCode {
+				t.Errorf("got exit code %d (%v), want %d", code, err, test.wantExitCode)
+			}
+		})
+	}
+}
+
+func TestLocalExecutorExecuteStream(t *testing.T) {
+	useLocalShell(t)
+
+	var mutex sync.Mutex
+	stdout, stderr := strings.Builder{}, strings.Builder{}
+	err := localExecutor{}.ExecuteStream(context.Background(), `echo one; echo "$GREETING" >&2; echo two; exit 2`, map[string]string{"GREETING": "hello"}, func(out string, errOut string) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		stdout.WriteString(out)
+		stderr.WriteString(errOut)
+	})
+
+	if stdout.String() != "one\ntwo\n" || stderr.String() != "hello\n" {
+		t.Errorf("got stdout %q and stderr %q", stdout.String(), stderr.String())
+	}
+	if code := ExitCode(err); code != 2 {
+		t.Errorf("got exit code %d (%v), want 2", code, err)
+	}
+}
+
+func TestExportEnvIsReadBackByTheShell(t *testing.T) {
+	useLocalShell(t)
+
+	values := []string{"plain", "with spaces", `it's "quoted"`, "$HOME `id` $(id)", "line\nbreak", ""}
+	for _, value := range values {
+		output, err := localExecutor{}.Execute(context.Background(), exportEnv(map[string]string{"VALUE": value})+`printf %s "$VALUE"`, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(output) != value {
+			t.Errorf("exported %q, read back %q", value, output)
+		}
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	useLocalShell(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, cancelled := localExecutor{}.Execute(ctx, "sleep 1", nil)
+
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"no error", nil, 0},
+		{"error without an exit status", errors.New("dial failed"), -1},
+		{"cancelled command", cancelled, -1},
+	}
+
+	for _, test := range tests {
+		if got := ExitCode(test.err); got != test.want {
+			t.Errorf("%s: got exit code %d, want %d", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+//go:build !unix
+
+package commands
+
+import (
+	"os/exec"
+	"time"
+)
+
+// processGroup stops a command, process groups and signals are not supported on this platform
+type processGroup struct {
+	cmd *exec.Cmd
+}
+
+func newProcessGroup(cmd *exec.Cmd) *processGroup {
+	return &processGroup{cmd: cmd}
+}
+
+// terminate kills the process
+func (g *processGroup) terminate(killDelay time.Duration) error {
+	return g.cmd.Process.Kill()
+}
+
+func (g *processGroup) markExited() {}
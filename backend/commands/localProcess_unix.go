@@ -0,0 +1,53 @@
+//go:build unix
+
+package commands
+
+import (
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// processGroup stops a command with its children, the command runs in its own process group
+type processGroup struct {
+	cmd       *exec.Cmd
+	mutex     sync.Mutex
+	killTimer *time.Timer
+	exited    bool
+}
+
+func newProcessGroup(cmd *exec.Cmd) *processGroup {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	return &processGroup{cmd: cmd}
+}
+
+// terminate sends SIGTERM to the process group and SIGKILL if it is still alive after the delay
+func (g *processGroup) terminate(killDelay time.Duration) error {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	pgid := -g.cmd.Process.Pid
+	if g.killTimer == nil {
+		g.killTimer = time.AfterFunc(killDelay, func() {
+			g.mutex.Lock()
+			defer g.mutex.Unlock()
+			if !g.exited {
+				syscall.Kill(pgid, syscall.SIGKILL)
+			}
+		})
+	}
+	return syscall.Kill(pgid, syscall.SIGTERM)
+}
+
+// markExited must be called once `cmd.Wait` returned, the pid may then be reused by another process
+// Children left behind by a terminated command are killed right away, the group can not be reused while they are alive
+func (g *processGroup) markExited() {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	g.exited = true
+	if g.killTimer != nil && g.killTimer.Stop() {
+		syscall.Kill(-g.cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
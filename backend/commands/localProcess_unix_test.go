@@ -0,0 +1,47 @@
+//go:build unix
+
+package commands
+
+import (
+	"context"
+	"os/exec"
+	"runny-code/common"
+	"testing"
+	"time"
+)
+
+func TestProcessGroupKillsAfterDelay(t *testing.T) {
+	common.Local_Shell_Env = "/bin/sh"
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	// the shell ignores SIGTERM, only the SIGKILL of the group stops it
+	start := time.Now()
+	_, err := localExecutor{}.Execute(ctx, "trap '' TERM; sleep 30", nil)
+	if err == nil {
+		t.Fatal("expected the command to be stopped")
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("command stopped after %s, want about the 5s kill delay", elapsed)
+	}
+}
+
+func TestProcessGroupStopsKillTimerOnExit(t *testing.T) {
+	cmd := exec.Command("/bin/sh", "-c", "sleep 0.05")
+	group := newProcessGroup(cmd)
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	// exits on SIGTERM, long before the kill delay
+	if err := group.terminate(time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	cmd.Wait()
+	group.markExited()
+
+	if group.killTimer.Stop() {
+		t.Error("the kill timer is still pending after the process exited")
+	}
+}
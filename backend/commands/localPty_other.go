@@ -0,0 +1,17 @@
+//go:build !unix
+
+package commands
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+)
+
+func startPty(cmd *exec.Cmd, size WindowSize) (*os.File, error) {
+	return nil, errors.New("tty mode is not supported by the local executor on this platform")
+}
+
+func resizePty(ptmx *os.File, size WindowSize) error {
+	return nil
+}
@@ -0,0 +1,21 @@
+//go:build unix
+
+package commands
+
+import (
+	"os"
+	"os/exec"
+
+	"github.com/creack/pty"
+)
+
+// startPty starts the command attached to a new pseudo terminal and returns its controlling side
+func startPty(cmd *exec.Cmd, size WindowSize) (*os.File, error) {
+	// the pty makes the process a session leader, a separate process group is not allowed
+	cmd.SysProcAttr = nil
+	return pty.StartWithSize(cmd, &pty.Winsize{Cols: uint16(size.Cols), Rows: uint16(size.Rows)})
+}
+
+func resizePty(ptmx *os.File, size WindowSize) error {
+	return pty.Setsize(ptmx, &pty.Winsize{Cols: uint16(size.Cols), Rows: uint16(size.Rows)})
+}
@@ -1,16 +1,85 @@
 package commands
 
+import "runny-code/common"
+
+// ParseCommands parses a commands.txt file, or a YAML or JSON catalog by its extension
 func ParseCommands(filePath string) ([]ParsedCommand, error) {
-	parsedCommandsList, err := parseCommandsFile(filePath)
+	parseFile := parseCommandsFile
+	if IsCatalogFile(filePath) {
+		parseFile = parseCatalogFile
+	}
+
+	parsedCommandsList, err := parseFile(filePath)
 	if err != nil {
 		return nil, err
 	}
 
 	commandsArr := []ParsedCommand{}
 	for _, parsedCommand := range parsedCommandsList {
-		parsedCommand.Variables = ParseVariables(parsedCommand.Command)
+		parsedCommand.Variables = mergeVariables(parsedCommand.Variables, ParseVariables(parsedCommand.Command))
+		parsedCommand.File = filePath
 		commandsArr = append(commandsArr, parsedCommand)
 	}
 
 	return commandsArr, nil
 }
+
+// CommandsFiles returns the existing commands files, commands.txt first then the catalogs
+func CommandsFiles() ([]string, error) {
+	files := []string{}
+	for _, filePath := range []string{common.CommandsFile, common.CommandsYAMLFile, common.CommandsJSONFile} {
+		exists, err := fileExists(filePath)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			files = append(files, filePath)
+		}
+	}
+	return files, nil
+}
+
+// LoadCommands parses all the commands files
+func LoadCommands() ([]ParsedCommand, error) {
+	files, err := CommandsFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	commandsList := []ParsedCommand{}
+	for _, filePath := range files {
+		parsed, err := ParseCommands(filePath)
+		if err != nil {
+			return nil, err
+		}
+		commandsList = append(commandsList, parsed...)
+	}
+
+	return commandsList, nil
+}
+
+// AddTargetFile is where new commands are added, commands.txt unless only catalogs are used
+func AddTargetFile() (string, error) {
+	files, err := CommandsFiles()
+	if err != nil {
+		return "", err
+	}
+	if len(files) == 0 {
+		return common.CommandsFile, nil
+	}
+	return files[0], nil
+}
+
+// HasCatalogFile reports whether a YAML or JSON catalog exists, commands.txt is then optional
+func HasCatalogFile() (bool, error) {
+	files, err := CommandsFiles()
+	if err != nil {
+		return false, err
+	}
+	for _, filePath := range files {
+		if IsCatalogFile(filePath) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
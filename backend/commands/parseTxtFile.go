@@ -1,48 +1,47 @@
 package commands
 
 import (
-	"bufio"
-	"os"
 	"regexp"
+	"slices"
 	"strings"
 )
 
-
 var NameDirectiveRe = regexp.MustCompile(`^\s*(?:@name)(.*)$`)
 var GroupDirectiveRe = regexp.MustCompile(`^\s*(?:@group)(.*)$`)
 var DescDirectiveRe = regexp.MustCompile(`^\s*(?:@description|@desc)(.*)$`)
+var ExecutorDirectiveRe = regexp.MustCompile(`^\s*(?:@executor)(.*)$`)
+var HostDirectiveRe = regexp.MustCompile(`^\s*(?:@host)(.*)$`)
+var TimeoutDirectiveRe = regexp.MustCompile(`^\s*(?:@timeout)(.*)$`)
+var TtyDirectiveRe = regexp.MustCompile(`^\s*(?:@tty)\s*$`)
 
 func parseCommandsFile(filePath string) (commands []ParsedCommand, err error) {
-	file, err := os.Open(filePath)
+	entries, err := readFileEntries(filePath)
 	if err != nil {
 		return nil, err
 	}
-	defer file.Close()
 
 	commands = []ParsedCommand{}
 
-	// Read the file line by line
-	scanner := bufio.NewScanner(file)
-	previousLines := []string{}
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-
+	for index, entry := range entries {
 		// Skip empty, comments and directives lines
-		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "@") {
-			previousLines = append(previousLines, line)
+		if !entry.isCommand() {
 			continue
 		}
 
 		name := ""
 		group := ""
 		description := ""
+		executor := ""
+		hostNames := []string{}
+		timeout := ""
+		tty := false
 
 		// loop backwards over previous lines to find directives
-		for i := len(previousLines) - 1; i >= 0; i-- {
-			previousLine := previousLines[i]
+		for i := index - 1; i >= 0; i-- {
+			previousLine := entries[i].line
 
-			// Stop on first empty or comment line
-			if previousLine == "" || strings.HasPrefix(previousLine, "#") {
+			// Stop on first empty, comment or command line
+			if previousLine == "" || strings.HasPrefix(previousLine, "#") || entries[i].isCommand() {
 				break
 			}
 
@@ -61,15 +60,41 @@ func parseCommandsFile(filePath string) (commands []ParsedCommand, err error) {
 				description = strings.TrimSpace(matchDesc[1])
 				continue
 			}
+			matchExecutor := ExecutorDirectiveRe.FindStringSubmatch(previousLine)
+			if len(matchExecutor) > 0 {
+				executor = strings.TrimSpace(matchExecutor[1])
+				continue
+			}
+			matchHost := HostDirectiveRe.FindStringSubmatch(previousLine)
+			if len(matchHost) > 0 {
+				hostNames = parseHostNames(matchHost[1])
+				continue
+			}
+			matchTimeout := TimeoutDirectiveRe.FindStringSubmatch(previousLine)
+			if len(matchTimeout) > 0 {
+				timeout = strings.TrimSpace(matchTimeout[1])
+				continue
+			}
+			if TtyDirectiveRe.MatchString(previousLine) {
+				tty = true
+				continue
+			}
 		}
 
-		commands = append(commands, ParsedCommand{Command: line, Name: name, Group: group, Description: description})
-	}
-
-	// Check for errors during scanning
-	if err = scanner.Err(); err != nil {
-		return nil, err
+		commands = append(commands, ParsedCommand{Command: entry.command, Name: name, Group: group, Description: description, Executor: executor, Hosts: hostNames, Timeout: timeout, TTY: tty})
 	}
 
 	return commands, nil
 }
+
+// parseHostNames splits the `@host` directive value, hosts are separated by `|`
+func parseHostNames(value string) []string {
+	hostNames := []string{}
+	for _, name := range strings.Split(value, "|") {
+		name = strings.TrimSpace(name)
+		if name != "" && !slices.Contains(hostNames, name) {
+			hostNames = append(hostNames, name)
+		}
+	}
+	return hostNames
+}
@@ -1,13 +1,15 @@
 package commands
 
 import (
+	"regexp"
 	"slices"
 	"strings"
 )
 
 // ParseVariables parses a command string and returns a list of ParsedVariables
+// A variable used several times is listed once, defined by its first use
 func ParseVariables(input string) []ParsedVariable {
-	matches := valuesRe.FindAllStringSubmatch(input, -1)
+	matches := findPlaceholders(valuesRe, input)
 
 	variables := []ParsedVariable{}
 
@@ -16,8 +18,12 @@ func ParseVariables(input string) []ParsedVariable {
 	for _, match := range matches {
 		variable := ParsedVariable{Values: []string{}}
 
-		for i, group := range match {
-			name := groupNames[i]
+		for i, name := range groupNames {
+			if i == 0 || match[2*i] < 0 {
+				continue
+			}
+			group := input[match[2*i]:match[2*i+1]]
+
 			switch name {
 			case "name":
 				variable.Name = group
@@ -32,12 +38,79 @@ func ParseVariables(input string) []ParsedVariable {
 			}
 		}
 
+		if findVariable(&variables, variable.Name) != nil {
+			continue
+		}
 		variables = append(variables, variable)
 	}
 
 	return variables
 }
 
+// mergeVariables returns the declared variables then the ones defined by placeholders that are not declared
+// Catalogs declare variables in `variables`, commands.txt only with placeholders
+func mergeVariables(declared []ParsedVariable, defined []ParsedVariable) []ParsedVariable {
+	variables := slices.Clone(declared)
+	for _, variable := range defined {
+		if findVariable(&variables, variable.Name) == nil {
+			variables = append(variables, variable)
+		}
+	}
+	return variables
+}
+
+// placeholderOf writes the variable as the placeholder defining it, the reverse of ParseVariables
+func placeholderOf(variable ParsedVariable) string {
+	placeholder := "${" + variable.Name
+	if variable.Optional {
+		placeholder += "?"
+	}
+	if variable.Type != "" {
+		placeholder += ":" + variable.Type
+	}
+	if variable.Default != "" {
+		placeholder += "=" + variable.Default
+	}
+	if len(variable.Values) > 0 {
+		placeholder += "[" + strings.Join(variable.Values, "|") + "]"
+	}
+	return placeholder + "}"
+}
+
+// rewritePlaceholders replaces each placeholder, escaped ones are kept
+// replace receives the name of the variable and whether it is its first use
+func rewritePlaceholders(command string, replace func(name string, first bool) string) string {
+	rewritten := strings.Builder{}
+	seen := map[string]bool{}
+	lastIndex := 0
+	for _, match := range findPlaceholders(PlaceholderRe, command) {
+		name := command[match[2]:match[3]]
+		rewritten.WriteString(command[lastIndex:match[0]])
+		rewritten.WriteString(replace(name, !seen[name]))
+		seen[name] = true
+		lastIndex = match[1]
+	}
+	rewritten.WriteString(command[lastIndex:])
+	return rewritten.String()
+}
+
+// findPlaceholders returns the submatch indexes of the placeholders
+// `$${...}` is escaped and skipped, so scripts can use the shell's own `${...}` expansions
+func findPlaceholders(re *regexp.Regexp, input string) [][]int {
+	matches := [][]int{}
+	for _, match := range re.FindAllStringSubmatchIndex(input, -1) {
+		if isEscapedPlaceholder(input, match[0]) {
+			continue
+		}
+		matches = append(matches, match)
+	}
+	return matches
+}
+
+func isEscapedPlaceholder(input string, start int) bool {
+	return start > 0 && input[start-1] == '$'
+}
+
 // verifyType processes and validates types
 func verifyType(t string) string {
 	if slices.Contains(validTypes, t) {
@@ -48,7 +121,12 @@ func verifyType(t string) string {
 
 // handleValues processes values and sets restricted or default flags
 func handleValues(values string) ([]string, bool) {
-	valuesList := removeDuplicates(strings.Split(values, "|"))
+	return handleValuesList(strings.Split(values, "|"))
+}
+
+// handleValuesList is handleValues for values that are already split, e.g. the `values` of a catalog variable
+func handleValuesList(values []string) ([]string, bool) {
+	valuesList := removeDuplicates(values)
 	valuesList = slices.DeleteFunc(valuesList, func(s string) bool {
 		return s == ""
 	})
@@ -0,0 +1,95 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"runny-code/changes"
+	"runny-code/common"
+	"runny-code/registry"
+)
+
+var commandsRegistry registry.Registry[ParsedCommand]
+
+// List returns the loaded commands, the list must not be modified
+func List() []ParsedCommand {
+	return commandsRegistry.List()
+}
+
+// Snapshot returns the loaded commands with their version, the list must not be modified
+func Snapshot() ([]ParsedCommand, uint64) {
+	return commandsRegistry.Snapshot()
+}
+
+// Version is incremented each time the loaded commands change
+func Version() uint64 {
+	return commandsRegistry.Version()
+}
+
+// Reload parses the commands files and replaces the loaded commands, they are kept when a file fails to parse
+func Reload() error {
+	return Update(func(current []ParsedCommand) error { return nil })
+}
+
+// Update calls change with the loaded commands, e.g. to check them and write to a commands file, then reloads the commands
+// Updates are serialized and the UIs are notified when the commands changed
+// When the change or the reload fails, the files written by the change are restored so they keep matching the loaded commands
+func Update(change func(current []ParsedCommand) error) error {
+	version, changed, err := commandsRegistry.Update(func(current []ParsedCommand) ([]ParsedCommand, error) {
+		previous := commandsFilesContent()
+
+		err := change(current)
+		if err != nil {
+			restoreCommandsFiles(previous)
+			return nil, err
+		}
+
+		commandsList, err := LoadCommands()
+		if err != nil {
+			restoreCommandsFiles(previous)
+			return nil, err
+		}
+		return commandsList, nil
+	})
+	if changed {
+		changes.Publish(changes.Commands, version)
+	}
+	return err
+}
+
+// commandsFilesContent returns the content of the commands files by path, nil for the missing ones
+// Files that can not be read are left out, so they are never restored
+func commandsFilesContent() map[string][]byte {
+	contents := map[string][]byte{}
+	for _, filePath := range []string{common.CommandsFile, common.CommandsYAMLFile, common.CommandsJSONFile} {
+		content, err := os.ReadFile(filePath)
+		if err == nil {
+			contents[filePath] = content
+		} else if os.IsNotExist(err) {
+			contents[filePath] = nil
+		}
+	}
+	return contents
+}
+
+// restoreCommandsFiles puts back the files that differ from their previous content
+// Unchanged files are not written, so an edit made on disk is not reverted when it is what fails to parse
+func restoreCommandsFiles(previous map[string][]byte) {
+	current := commandsFilesContent()
+	for filePath, content := range previous {
+		currentContent, readable := current[filePath]
+		if readable && (content == nil) == (currentContent == nil) && bytes.Equal(content, currentContent) {
+			continue
+		}
+
+		var err error
+		if content == nil {
+			err = os.Remove(filePath)
+		} else {
+			err = common.WriteFileAtomic(filePath, content, 0644)
+		}
+		if err != nil && !os.IsNotExist(err) {
+			fmt.Fprintf(os.Stderr, "Failed to restore %s: %s\n", filePath, err)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package commands
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runny-code/common"
+	"testing"
+)
+
+// useCommandsFile runs the test from a directory whose config has the commands file, and loads it
+func useCommandsFile(t *testing.T, content string) {
+	root := t.TempDir()
+	for _, dir := range []string{"config", "backend"} {
+		if err := os.Mkdir(filepath.Join(root, dir), 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(filepath.Join(root, "backend"))
+
+	if err := os.WriteFile(common.CommandsFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Reload(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUpdateRestoresFilesWhenReloadFails(t *testing.T) {
+	const content = "@name Hello\necho hello\n"
+	useCommandsFile(t, content)
+	version := Version()
+
+	err := Update(func(current []ParsedCommand) error {
+		if err := os.WriteFile(common.CommandsFile, []byte(content+"\n@name Other\necho other\n"), 0644); err != nil {
+			return err
+		}
+		return os.WriteFile(common.CommandsYAMLFile, []byte("commands: [\n"), 0644)
+	})
+	if err == nil {
+		t.Fatal("expected the update to fail")
+	}
+
+	restored, err := os.ReadFile(common.CommandsFile)
+	if err != nil || string(restored) != content {
+		t.Errorf("commands file is %q (%v), want it restored", restored, err)
+	}
+	if _, err := os.Stat(common.CommandsYAMLFile); !os.IsNotExist(err) {
+		t.Errorf("catalog created by the update still exists: %v", err)
+	}
+	if Version() != version || len(List()) != 1 {
+		t.Errorf("loaded commands changed to %+v", List())
+	}
+}
+
+func TestUpdateRestoresFilesWhenChangeFails(t *testing.T) {
+	const content = "@name Hello\necho hello\n"
+	useCommandsFile(t, content)
+
+	failure := errors.New("failed half way")
+	err := Update(func(current []ParsedCommand) error {
+		if err := os.WriteFile(common.CommandsFile, []byte("@name Other\necho other\n"), 0644); err != nil {
+			return err
+		}
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("got error %v, want %v", err, failure)
+	}
+
+	restored, err := os.ReadFile(common.CommandsFile)
+	if err != nil || string(restored) != content {
+		t.Errorf("commands file is %q (%v), want it restored", restored, err)
+	}
+}
+
+func TestUpdateKeepsEditsMadeOnDisk(t *testing.T) {
+	useCommandsFile(t, "@name Hello\necho hello\n")
+
+	// a catalog edited outside of the update is left for the user to fix
+	const broken = "commands: [\n"
+	if err := os.WriteFile(common.CommandsYAMLFile, []byte(broken), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Reload(); err == nil {
+		t.Fatal("expected the reload to fail")
+	}
+
+	catalog, err := os.ReadFile(common.CommandsYAMLFile)
+	if err != nil || string(catalog) != broken {
+		t.Errorf("catalog is %q (%v), want the edit kept", catalog, err)
+	}
+}
@@ -0,0 +1,131 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"runny-code/common"
+	"runny-code/hosts"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// sshAuth holds the authentication methods for a host and the agent connection if one is used
+type sshAuth struct {
+	methods   []ssh.AuthMethod
+	agent     agent.ExtendedAgent
+	agentConn net.Conn
+}
+
+func (a *sshAuth) Close() {
+	if a.agentConn != nil {
+		a.agentConn.Close()
+	}
+}
+
+// createSshAuth builds the fallback chain of authentication methods for a host
+// Order: private key and agent keys (one public key method), then password and keyboard-interactive
+func createSshAuth(host hosts.Host) (*sshAuth, error) {
+	auth := &sshAuth{}
+	signers := []ssh.Signer{}
+
+	if host.PrivateKey != "" {
+		signer, err := loadPrivateKey(host.PrivateKey, host.Passphrase)
+		if err != nil {
+			return nil, err
+		}
+		signers = append(signers, signer)
+	}
+
+	if host.UseAgent || host.ForwardAgent {
+		err := auth.connectAgent()
+		if err != nil && host.PrivateKey == "" && host.Password == "" {
+			return nil, err
+		}
+	}
+
+	if len(signers) > 0 || auth.agent != nil {
+		auth.methods = append(auth.methods, ssh.PublicKeysCallback(func() ([]ssh.Signer, error) {
+			if auth.agent == nil {
+				return signers, nil
+			}
+
+			agentSigners, err := auth.agent.Signers()
+			if err != nil {
+				return signers, nil
+			}
+			return append(signers, agentSigners...), nil
+		}))
+	}
+
+	if host.Password != "" {
+		auth.methods = append(auth.methods, ssh.Password(host.Password))
+		auth.methods = append(auth.methods, ssh.KeyboardInteractive(func(name, instruction string, questions []string, echos []bool) ([]string, error) {
+			answers := make([]string, len(questions))
+			for i := range questions {
+				answers[i] = host.Password
+			}
+			return answers, nil
+		}))
+	}
+
+	if len(auth.methods) == 0 {
+		auth.Close()
+		return nil, fmt.Errorf(`host "%s" has no authentication method configured`, host.Name)
+	}
+
+	return auth, nil
+}
+
+// connectAgent connects to the ssh agent listening on `SSH_AUTH_SOCK`
+func (a *sshAuth) connectAgent() error {
+	socket := os.Getenv("SSH_AUTH_SOCK")
+	if socket == "" {
+		return errors.New("ssh agent requested but SSH_AUTH_SOCK is not set")
+	}
+
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		return fmt.Errorf("failed to connect to ssh agent: %w", err)
+	}
+
+	a.agentConn = conn
+	a.agent = agent.NewClient(conn)
+	return nil
+}
+
+// loadPrivateKey reads a PEM or OpenSSH private key, relative paths are resolved from the config directory
+func loadPrivateKey(keyPath string, passphrase string) (ssh.Signer, error) {
+	if !filepath.IsAbs(keyPath) {
+		keyPath = filepath.Join(common.ConfigDir, keyPath)
+	}
+
+	keyBytes, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key: %w", err)
+	}
+
+	signer, err := ssh.ParsePrivateKey(keyBytes)
+	if err == nil {
+		return signer, nil
+	}
+
+	var passphraseErr *ssh.PassphraseMissingError
+	if !errors.As(err, &passphraseErr) {
+		return nil, fmt.Errorf("failed to parse private key '%s': %w", keyPath, err)
+	}
+
+	if passphrase == "" {
+		return nil, fmt.Errorf("private key '%s' is encrypted but no passphrase was provided", keyPath)
+	}
+
+	signer, err = ssh.ParsePrivateKeyWithPassphrase(keyBytes, []byte(passphrase))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt private key '%s': %w", keyPath, err)
+	}
+
+	return signer, nil
+}
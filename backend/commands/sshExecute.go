@@ -1,60 +1,46 @@
 package commands
 
 import (
-	"io"
-	"regexp"
-	"runny-code/common"
+	"context"
+	"runny-code/hosts"
 
 	"golang.org/x/crypto/ssh"
 )
 
-func createSession() (*ssh.Client, *ssh.Session, error) {
-	config := &ssh.ClientConfig{
-		User: common.SSH_User_Env,
-		Auth: []ssh.AuthMethod{
-			ssh.Password(common.SSH_Password_Env),
-		},
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(), // For demo; avoid in production
-	}
-
-	// Connect to the remote server
-	client, err := ssh.Dial("tcp", common.SSH_Host_Env+":"+common.SSH_Port_Env, config)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	session, err := client.NewSession()
-	if err != nil {
-		return nil, nil, err
-	}
-
-	return client, session, nil
+// sshExecutor runs commands on a remote host from the hosts inventory
+type sshExecutor struct {
+	host hosts.Host
 }
 
-func SShExecute(command string) (outputByte []byte, err error) {
-	client, session, err := createSession()
+func (e sshExecutor) Execute(ctx context.Context, command string, env map[string]string) (outputByte []byte, err error) {
+	session, release, err := createSession(e.host)
 	if err != nil {
 		return
 	}
-	defer session.Close()
-	defer client.Close()
+	defer release()
+
+	stopWatching := watchSessionContext(ctx, session)
+	defer stopWatching()
 
 	// Run a command and print output
-	outputByte, err = session.CombinedOutput(command)
+	outputByte, err = session.CombinedOutput(exportEnv(env) + command)
 	if err != nil {
+		err = contextError(ctx, err)
 		return
 	}
 
 	return
 }
 
-func SShExecuteStream(command string, outputHandler func(stdout string, stderr string)) error {
-	client, session, err := createSession()
+func (e sshExecutor) ExecuteStream(ctx context.Context, command string, env map[string]string, outputHandler func(stdout string, stderr string)) error {
+	session, release, err := createSession(e.host)
 	if err != nil {
 		return err
 	}
-	defer session.Close()
-	defer client.Close()
+	defer release()
+
+	stopWatching := watchSessionContext(ctx, session)
+	defer stopWatching()
 
 	// Get stdout and stderr pipes
 	stdout, err := session.StdoutPipe()
@@ -67,7 +53,7 @@ func SShExecuteStream(command string, outputHandler func(stdout string, stderr s
 	}
 
 	// Start the command
-	if err := session.Start(command); err != nil {
+	if err := session.Start(exportEnv(env) + command); err != nil {
 		return err
 	}
 
@@ -82,33 +68,60 @@ func SShExecuteStream(command string, outputHandler func(stdout string, stderr s
 	}
 
 	// Wait for the command to finish
-	return session.Wait()
+	return contextError(ctx, session.Wait())
 }
 
-var rmLnRe = regexp.MustCompile(`.*?\r`)
+func (e sshExecutor) ExecuteInteractive(ctx context.Context, command string, env map[string]string, terminal Terminal) error {
+	session, release, err := createSession(e.host)
+	if err != nil {
+		return err
+	}
+	defer release()
 
-// Stream output by sending the entire accumulated output on each update
-func streamOutput(reader io.Reader, outputHandler func(stdout string, stderr string), doneChan chan error, isError bool) {
-	currentOutput := ""
-	buffer := make([]byte, 1024)
+	stopWatching := watchSessionContext(ctx, session)
+	defer stopWatching()
 
-	for {
-		n, err := reader.Read(buffer)
+	size := terminal.Size.orDefault()
+	modes := ssh.TerminalModes{
+		ssh.ECHO:          1,
+		ssh.TTY_OP_ISPEED: 14400,
+		ssh.TTY_OP_OSPEED: 14400,
+	}
+	if err := session.RequestPty("xterm-256color", size.Rows, size.Cols, modes); err != nil {
+		return err
+	}
 
-		if n > 0 {
-			currentOutput += string(buffer[:n])
-			currentOutput = rmLnRe.ReplaceAllString(currentOutput, "")
+	session.Stdin = terminal.Stdin
+	session.Stdout = terminal.Output
+	session.Stderr = terminal.Output
 
-			if isError {
-				outputHandler("", currentOutput)
-			} else {
-				outputHandler(currentOutput, "")
-			}
+	if err := session.Start(exportEnv(env) + command); err != nil {
+		return err
+	}
+
+	go func() {
+		for size := range terminal.Resize {
+			size = size.orDefault()
+			session.WindowChange(size.Rows, size.Cols)
 		}
+	}()
+
+	return contextError(ctx, session.Wait())
+}
 
-		if err != nil {
-			doneChan <- nil
-			return
+// watchSessionContext signals the remote process and closes the session when the context is done
+// The returned function stops watching and must be called once the command finishes
+func watchSessionContext(ctx context.Context, session *ssh.Session) func() {
+	done := make(chan struct{})
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			session.Signal(ssh.SIGTERM)
+			session.Close()
+		case <-done:
 		}
-	}
+	}()
+
+	return func() { close(done) }
 }
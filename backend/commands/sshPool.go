@@ -0,0 +1,273 @@
+package commands
+
+import (
+	"errors"
+	"maps"
+	"runny-code/common"
+	"runny-code/hosts"
+	"slices"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// sshPoolEntry holds the shared connection to one host, sessions are multiplexed over it
+// The mutex is never held while dialing or waiting on the network
+type sshPoolEntry struct {
+	mutex        sync.Mutex
+	client       *ssh.Client
+	forwardAgent bool
+	sessions     int
+	lastUsed     time.Time
+	dialing      *sshDial // the dial in progress, shared by the callers waiting for a connection
+	generation   int      // incremented when the connection is closed for everyone, dials started before are discarded
+}
+
+// sshDial is a dial in progress, its result is set before done is closed
+type sshDial struct {
+	done         chan struct{}
+	client       *ssh.Client
+	forwardAgent bool
+	err          error
+}
+
+// keepAliveTimeout closes connections that do not answer a keep alive in time, e.g. half-open TCP connections
+const keepAliveTimeout = 15 * time.Second
+
+var errPoolClosed = errors.New("the SSH connection was closed while connecting, try again")
+var errKeepAliveTimeout = errors.New("keep alive timed out")
+
+var sshPoolMutex sync.Mutex
+var sshPool = map[string]*sshPoolEntry{}
+
+func sshPoolKey(host hosts.Host) string {
+	return host.Name + "|" + host.User + "@" + host.Addr()
+}
+
+func getSshPoolEntry(host hosts.Host) *sshPoolEntry {
+	sshPoolMutex.Lock()
+	defer sshPoolMutex.Unlock()
+
+	key := sshPoolKey(host)
+	entry, ok := sshPool[key]
+	if !ok {
+		entry = &sshPoolEntry{}
+		sshPool[key] = entry
+	}
+	return entry
+}
+
+// createSession opens a new session on the pooled connection of the host
+// Dials when there is no connection and reconnects once if the connection is broken
+// The returned release function must be called when the session is done
+func createSession(host hosts.Host) (*ssh.Session, func(), error) {
+	entry := getSshPoolEntry(host)
+
+	for attempt := range 2 {
+		client, forwardAgent, err := entry.connect(host)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		session, err := client.NewSession()
+		if err != nil {
+			// broken connection, drop it and dial again
+			entry.drop(client)
+			if attempt == 0 {
+				continue
+			}
+			return nil, nil, err
+		}
+
+		if forwardAgent {
+			err = agent.RequestAgentForwarding(session)
+			if err != nil {
+				session.Close()
+				return nil, nil, err
+			}
+		}
+
+		entry.mutex.Lock()
+		entry.sessions++
+		entry.mutex.Unlock()
+
+		release := func() {
+			session.Close()
+
+			entry.mutex.Lock()
+			defer entry.mutex.Unlock()
+			entry.sessions--
+			entry.lastUsed = time.Now()
+		}
+
+		return session, release, nil
+	}
+
+	return nil, nil, nil // unreachable
+}
+
+// connect returns the pooled connection, or dials it once for all the callers waiting for it
+func (entry *sshPoolEntry) connect(host hosts.Host) (*ssh.Client, bool, error) {
+	entry.mutex.Lock()
+	if entry.client != nil {
+		client, forwardAgent := entry.client, entry.forwardAgent
+		entry.lastUsed = time.Now() // not idle, so the keep alive does not close it before the session opens
+		entry.mutex.Unlock()
+		return client, forwardAgent, nil
+	}
+
+	// another caller is already dialing
+	if dial := entry.dialing; dial != nil {
+		entry.mutex.Unlock()
+		<-dial.done
+		return dial.client, dial.forwardAgent, dial.err
+	}
+
+	dial := &sshDial{done: make(chan struct{})}
+	entry.dialing = dial
+	generation := entry.generation
+	entry.mutex.Unlock()
+
+	dial.client, dial.forwardAgent, dial.err = dialClient(host)
+
+	entry.mutex.Lock()
+	entry.dialing = nil
+	if dial.err == nil && entry.generation != generation {
+		// closed for everyone while dialing, e.g. a host key was revoked after it was checked
+		dial.client.Close()
+		dial.client, dial.err = nil, errPoolClosed
+	}
+	if dial.err == nil {
+		entry.client = dial.client
+		entry.forwardAgent = dial.forwardAgent
+		entry.lastUsed = time.Now()
+		go entry.keepAlive(dial.client)
+	}
+	entry.mutex.Unlock()
+	close(dial.done)
+
+	return dial.client, dial.forwardAgent, dial.err
+}
+
+// drop removes a broken connection from the pool and closes it
+func (entry *sshPoolEntry) drop(client *ssh.Client) {
+	entry.mutex.Lock()
+	if entry.client == client {
+		entry.client = nil
+	}
+	entry.mutex.Unlock()
+	client.Close()
+}
+
+// dialClient connects and authenticates to the host
+func dialClient(host hosts.Host) (client *ssh.Client, forwardAgent bool, err error) {
+	auth, err := createSshAuth(host)
+	if err != nil {
+		return nil, false, err
+	}
+
+	config := &ssh.ClientConfig{
+		User:              host.User,
+		Auth:              auth.methods,
+		HostKeyCallback:   hosts.HostKeyCallback(),
+		HostKeyAlgorithms: hosts.HostKeyAlgorithms(host.Addr()),
+		Timeout:           15 * time.Second,
+	}
+
+	// Connect to the remote server
+	client, err = ssh.Dial("tcp", host.Addr(), config)
+	if err != nil {
+		auth.Close()
+		return nil, false, err
+	}
+
+	// Keep the agent connection open as long as the client lives
+	go func() {
+		client.Wait()
+		auth.Close()
+	}()
+
+	if host.ForwardAgent && auth.agent != nil {
+		err = agent.ForwardToAgent(client, auth.agent)
+		if err != nil {
+			client.Close()
+			return nil, false, err
+		}
+		forwardAgent = true
+	}
+
+	return client, forwardAgent, nil
+}
+
+// keepAlive checks the connection health every `SSH_KEEPALIVE_INTERVAL`
+// Closes the connection when it stops responding or stays unused longer than `SSH_IDLE_TIMEOUT`
+func (entry *sshPoolEntry) keepAlive(client *ssh.Client) {
+	interval := common.ParseDuration(common.SSH_Keepalive_Interval_Env, 30*time.Second)
+	idleTimeout := common.ParseDuration(common.SSH_Idle_Timeout_Env, 5*time.Minute)
+
+	ticker := time.NewTicker(min(interval, idleTimeout))
+	defer ticker.Stop()
+
+	for range ticker.C {
+		entry.mutex.Lock()
+		// replaced or closed by someone else
+		if entry.client != client {
+			entry.mutex.Unlock()
+			return
+		}
+		if entry.sessions == 0 && time.Since(entry.lastUsed) >= idleTimeout {
+			entry.client = nil
+			entry.mutex.Unlock()
+			client.Close()
+			return
+		}
+		entry.mutex.Unlock()
+
+		err := sendKeepAlive(client)
+		if err == nil {
+			continue
+		}
+
+		entry.drop(client)
+		return
+	}
+}
+
+// sendKeepAlive fails when the server does not answer in time, the client is then closed to unblock the request
+func sendKeepAlive(client *ssh.Client) error {
+	result := make(chan error, 1)
+	go func() {
+		_, _, err := client.SendRequest("keepalive@openssh.com", true, nil)
+		result <- err
+	}()
+
+	select {
+	case err := <-result:
+		return err
+	case <-time.After(keepAliveTimeout):
+		client.Close()
+		return errKeepAliveTimeout
+	}
+}
+
+// ClosePooledConnections closes every pooled connection, running sessions are terminated
+// Used after host keys are revoked so no connection verified with the old key is reused, dials in progress are discarded
+func ClosePooledConnections() {
+	sshPoolMutex.Lock()
+	entries := slices.Collect(maps.Values(sshPool))
+	sshPoolMutex.Unlock()
+
+	for _, entry := range entries {
+		entry.mutex.Lock()
+		client := entry.client
+		entry.client = nil
+		entry.generation++
+		entry.mutex.Unlock()
+
+		if client != nil {
+			client.Close()
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package commands
+
+import (
+	"io"
+	"unicode/utf8"
+)
+
+// Stream output by sending each new chunk as soon as it is read
+// A multi-byte character split between two reads is held back until it is complete
+func streamOutput(reader io.Reader, outputHandler func(stdout string, stderr string), doneChan chan error, isError bool) {
+	buffer := make([]byte, 32*1024)
+	pending := []byte{}
+
+	for {
+		n, err := reader.Read(buffer)
+
+		if n > 0 {
+			pending = append(pending, buffer[:n]...)
+			complete := completeRunesLength(pending)
+
+			if complete > 0 {
+				chunk := string(pending[:complete])
+				pending = pending[complete:]
+
+				if isError {
+					outputHandler("", chunk)
+				} else {
+					outputHandler(chunk, "")
+				}
+			}
+		}
+
+		if err != nil {
+			// flush whatever is left, even if it is not valid UTF-8
+			if len(pending) > 0 {
+				if isError {
+					outputHandler("", string(pending))
+				} else {
+					outputHandler(string(pending), "")
+				}
+			}
+
+			doneChan <- nil
+			return
+		}
+	}
+}
+
+// completeRunesLength returns the length of b without a trailing incomplete UTF-8 sequence
+func completeRunesLength(b []byte) int {
+	// a rune is at most 4 bytes, look for its start in the last 3 bytes
+	for i := 1; i <= 3 && i <= len(b); i++ {
+		start := len(b) - i
+		if !utf8.RuneStart(b[start]) {
+			continue
+		}
+		if !utf8.FullRune(b[start:]) {
+			return start
+		}
+		break
+	}
+	return len(b)
+}
@@ -0,0 +1,36 @@
+package commands
+
+import (
+	"context"
+	"io"
+)
+
+// WindowSize is the size of a terminal in characters
+type WindowSize struct {
+	Cols int `json:"cols"`
+	Rows int `json:"rows"`
+}
+
+// Terminal connects a command running in a pseudo terminal to the client
+// A pty merges stdout and stderr into Output
+type Terminal struct {
+	Stdin  io.Reader
+	Output io.Writer
+	Size   WindowSize
+	Resize <-chan WindowSize
+}
+
+// InteractiveExecutor runs commands attached to a pseudo terminal, used for `@tty` commands
+type InteractiveExecutor interface {
+	ExecuteInteractive(ctx context.Context, command string, env map[string]string, terminal Terminal) error
+}
+
+func (s WindowSize) orDefault() WindowSize {
+	if s.Cols <= 0 {
+		s.Cols = 80
+	}
+	if s.Rows <= 0 {
+		s.Rows = 24
+	}
+	return s
+}
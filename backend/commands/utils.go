@@ -2,8 +2,11 @@ package commands
 
 import (
 	"fmt"
+	"maps"
 	"os"
 	"regexp"
+	"slices"
+	"strings"
 	"unicode"
 )
 
@@ -43,6 +46,16 @@ func FindCommand(commands []ParsedCommand, name string) *ParsedCommand {
 	return nil
 }
 
+// Lookup returns the loaded command with the name and command, both are sent by the UI to tell apart commands with the same name
+func Lookup(name string, command string) *ParsedCommand {
+	for _, parsedCommand := range List() {
+		if parsedCommand.Name == name && parsedCommand.Command == command {
+			return &parsedCommand
+		}
+	}
+	return nil
+}
+
 func findVariable(variables *[]ParsedVariable, name string) *ParsedVariable {
 	for _, variable := range *variables {
 		if variable.Name == name {
@@ -98,3 +111,23 @@ func wrapInQuotes(str string) string {
 	escapedDoubleQuotesStr := unescapedDoubleQuotesRe.ReplaceAllString(str, `$1\$3`)
 	return fmt.Sprintf(`"%s"`, escapedDoubleQuotesStr)
 }
+
+// shellQuote wraps a string in single quotes so the shell treats it literally
+func shellQuote(str string) string {
+	return "'" + strings.ReplaceAll(str, "'", `'\''`) + "'"
+}
+
+// exportEnv builds a shell prefix that exports the given variables before a command
+// Used instead of `session.Setenv`, which most ssh servers reject unless listed in `AcceptEnv`
+func exportEnv(env map[string]string) string {
+	if len(env) == 0 {
+		return ""
+	}
+
+	prefix := ""
+	for _, key := range slices.Sorted(maps.Keys(env)) {
+		prefix += fmt.Sprintf("export %s=%s; ", key, shellQuote(env[key]))
+	}
+
+	return prefix
+}
@@ -0,0 +1,40 @@
+package commands
+
+import "testing"
+
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"", `''`},
+		{"plain", `'plain'`},
+		{"with spaces", `'with spaces'`},
+		{"it's", `'it'\''s'`},
+		{"$HOME", `'$HOME'`},
+	}
+
+	for _, test := range tests {
+		if got := shellQuote(test.value); got != test.want {
+			t.Errorf("shellQuote(%q) is %s, want %s", test.value, got, test.want)
+		}
+	}
+}
+
+func TestExportEnv(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{"no variables", nil, ""},
+		{"sorted by name", map[string]string{"B": "2", "A": "1"}, "export A='1'; export B='2'; "},
+		{"quoted values", map[string]string{"NAME": "it's me"}, `export NAME='it'\''s me'; `},
+	}
+
+	for _, test := range tests {
+		if got := exportEnv(test.env); got != test.want {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
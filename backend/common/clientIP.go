@@ -0,0 +1,48 @@
+package common
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ClientIP returns the address of the client
+// `X-Forwarded-For` is only trusted when `TRUST_PROXY_HEADERS` is true, each of the `TRUSTED_PROXY_HOPS` proxies
+// appends the address it received the request from, so the client is that many entries from the right
+// The entries further left are sent by the client itself and can be forged
+func ClientIP(r *http.Request) string {
+	if Trust_Proxy_Headers_Env == "true" {
+		forwardedFor := []string{}
+		for _, value := range r.Header.Values("X-Forwarded-For") {
+			for _, address := range strings.Split(value, ",") {
+				if address = strings.TrimSpace(address); address != "" {
+					forwardedFor = append(forwardedFor, address)
+				}
+			}
+		}
+
+		if len(forwardedFor) > 0 {
+			hops, err := strconv.Atoi(Trusted_Proxy_Hops_Env)
+			if err != nil || hops < 1 {
+				hops = 1
+			}
+			return forwardedFor[max(len(forwardedFor)-hops, 0)]
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// IsSecureRequest reports whether the client connected over TLS
+// `X-Forwarded-Proto` is only trusted when `TRUST_PROXY_HEADERS` is true
+func IsSecureRequest(r *http.Request) bool {
+	if r.TLS != nil {
+		return true
+	}
+	return Trust_Proxy_Headers_Env == "true" && r.Header.Get("X-Forwarded-Proto") == "https"
+}
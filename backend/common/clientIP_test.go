@@ -0,0 +1,46 @@
+package common
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name         string
+		trustProxy   string
+		hops         string
+		forwardedFor []string
+		want         string
+	}{
+		{"proxy headers ignored", "false", "1", []string{"203.0.113.7"}, "192.0.2.1"},
+		{"no forwarded header", "true", "1", nil, "192.0.2.1"},
+		{"single proxy", "true", "1", []string{"203.0.113.7"}, "203.0.113.7"},
+		{"forged entry before the proxy", "true", "1", []string{"10.0.0.1, 203.0.113.7"}, "203.0.113.7"},
+		{"two proxies", "true", "2", []string{"10.0.0.1, 203.0.113.7, 198.51.100.2"}, "203.0.113.7"},
+		{"several headers", "true", "1", []string{"10.0.0.1", "203.0.113.7"}, "203.0.113.7"},
+		{"fewer entries than hops", "true", "3", []string{"203.0.113.7, 198.51.100.2"}, "203.0.113.7"},
+		{"invalid hops", "true", "none", []string{"10.0.0.1, 203.0.113.7"}, "203.0.113.7"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			Trust_Proxy_Headers_Env = test.trustProxy
+			Trusted_Proxy_Hops_Env = test.hops
+			t.Cleanup(func() {
+				Trust_Proxy_Headers_Env = ""
+				Trusted_Proxy_Hops_Env = ""
+			})
+
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = "192.0.2.1:51234"
+			for _, value := range test.forwardedFor {
+				r.Header.Add("X-Forwarded-For", value)
+			}
+
+			if got := ClientIP(r); got != test.want {
+				t.Errorf("got %s, want %s", got, test.want)
+			}
+		})
+	}
+}
@@ -1,40 +1,97 @@
 package common
 
 import (
-	"crypto/rand"
-	"encoding/base64"
 	"os"
 	"slices"
 	"strings"
+	"time"
 )
 
 const FilesDir = "../files" // Dont use abs path it will break everything
+const ConfigDir = "../config"
 const StaticDir = "../webui/dist"
 const CommandsFile = "../config/commands.txt"
+const CommandsYAMLFile = "../config/commands.yaml" // catalogs, used alongside or instead of commands.txt
+const CommandsJSONFile = "../config/commands.json"
 const WebhooksFile = "../config/webhooks.json"
+const HostsFile = "../config/hosts.json"
+const KnownHostsFile = "../config/known_hosts"
+const JobsFile = "../config/jobs.json"
+const JobsOutputDir = "../config/jobs" // the output of each job, one file per job
+const AuditFile = "../config/audit.log"
+const UsersFile = "../config/users.json"
+const SessionsFile = "../config/sessions.json"
+const RolesFile = "../config/roles.json"
+const TokensFile = "../config/tokens.json"
+const SettingsFile = "../config/settings.json"
+const ClientCertsFile = "../config/client_certs.json"
 
 var App_ENV = os.Getenv("APP_ENV") // development | production
 
 var Allow_Command_Manipulation_Env = os.Getenv("ALLOW_COMMAND_MANIPULATION")
+var Watch_Config_Files_Env = os.Getenv("WATCH_CONFIG_FILES") // reload the commands and webhooks when their files change on disk
 
 var Include_Patterns_Env = strings.Split(os.Getenv("INCLUDED_PATTERNS"), " | ")
 var Exclude_Patterns_Env = strings.Split(os.Getenv("EXCLUDED_PATTERNS"), " | ")
 
-var Username_Env = os.Getenv("AUTH_USERNAME")
+var Username_Env = os.Getenv("AUTH_USERNAME") // the bootstrap admin, used when the users file has no users
 var Password_Env = os.Getenv("AUTH_PASSWORD")
+var Session_Idle_Timeout_Env = os.Getenv("SESSION_IDLE_TIMEOUT")
+var Session_Max_Age_Env = os.Getenv("SESSION_MAX_AGE")
+var Login_Max_Attempts_Env = os.Getenv("LOGIN_MAX_ATTEMPTS") // failed logins per IP or username before a lockout
+var Login_Lockout_Env = os.Getenv("LOGIN_LOCKOUT")           // first lockout, doubled with each further failure
+var Login_Max_Lockout_Env = os.Getenv("LOGIN_MAX_LOCKOUT")
+var Refuse_Default_Credentials_Env = os.Getenv("REFUSE_DEFAULT_CREDENTIALS") // refuse to start in production while admin/admin works
+
+// Single sign-on with an OpenID Connect provider, enabled when the issuer is set
+var OIDC_Issuer_Env = os.Getenv("OIDC_ISSUER")
+var OIDC_Client_ID_Env = os.Getenv("OIDC_CLIENT_ID")
+var OIDC_Client_Secret_Env = os.Getenv("OIDC_CLIENT_SECRET")
+var OIDC_Redirect_URL_Env = os.Getenv("OIDC_REDIRECT_URL") // empty to derive it from the request, e.g. https://host/login/oidc/callback
+var OIDC_Scopes_Env = os.Getenv("OIDC_SCOPES")             // separated by spaces
+var OIDC_Username_Claim_Env = os.Getenv("OIDC_USERNAME_CLAIM")
+var OIDC_Groups_Claim_Env = os.Getenv("OIDC_GROUPS_CLAIM") // nested claims with dots, e.g. realm_access.roles
+var OIDC_Role_Mapping_Env = os.Getenv("OIDC_ROLE_MAPPING") // e.g. `admins=admin | ops=operator`
+var OIDC_Default_Role_Env = os.Getenv("OIDC_DEFAULT_ROLE") // for users in no mapped group, empty to refuse them
 
 var SSH_User_Env = os.Getenv("SSH_USERNAME")
 var SSH_Password_Env = os.Getenv("SSH_PASSWORD")
 var SSH_Host_Env = os.Getenv("SSH_HOST")
 var SSH_Port_Env = os.Getenv("SSH_PORT")
+var SSH_Private_Key_Env = os.Getenv("SSH_PRIVATE_KEY") // relative to the config directory
+var SSH_Key_Passphrase_Env = os.Getenv("SSH_KEY_PASSPHRASE")
+var SSH_Use_Agent_Env = os.Getenv("SSH_USE_AGENT")
+var SSH_Forward_Agent_Env = os.Getenv("SSH_FORWARD_AGENT")
+var SSH_Host_Key_Checking_Env = os.Getenv("SSH_HOST_KEY_CHECKING") // strict | tofu | off
+var SSH_Keepalive_Interval_Env = os.Getenv("SSH_KEEPALIVE_INTERVAL")
+var SSH_Idle_Timeout_Env = os.Getenv("SSH_IDLE_TIMEOUT")
+
+var Executor_Env = os.Getenv("EXECUTOR") // ssh | local
+var Local_Shell_Env = os.Getenv("LOCAL_SHELL")
+var Command_Timeout_Env = os.Getenv("COMMAND_TIMEOUT") // e.g. 30s or 5m, empty for no timeout
+var Jobs_History_Limit_Env = os.Getenv("JOBS_HISTORY_LIMIT")
+
+var Trust_Proxy_Headers_Env = os.Getenv("TRUST_PROXY_HEADERS")
+var Trusted_Proxy_Hops_Env = os.Getenv("TRUSTED_PROXY_HOPS") // reverse proxies appending to `X-Forwarded-For` in front of the server
+var Cookie_Same_Site_Env = os.Getenv("COOKIE_SAME_SITE")     // lax | strict
 
 var Port = os.Getenv("PORT")
 var Webhook_Port = os.Getenv("WEBHOOK_PORT")
+
+// Serve HTTPS when set, PEM files relative to the config directory, reloaded when they change
+var TLS_Cert_File_Env = os.Getenv("TLS_CERT_FILE")
+var TLS_Key_File_Env = os.Getenv("TLS_KEY_FILE")
+var Webhook_TLS_Cert_File_Env = os.Getenv("WEBHOOK_TLS_CERT_FILE") // for the separate webhook server, defaults to TLS_CERT_FILE
+var Webhook_TLS_Key_File_Env = os.Getenv("WEBHOOK_TLS_KEY_FILE")
+
+// Client certificates signed by the CA file authenticate callers, each listener is configured on its own
+var TLS_Client_Auth_Env = os.Getenv("TLS_CLIENT_AUTH") // off | optional | require
+var TLS_Client_CA_File_Env = os.Getenv("TLS_CLIENT_CA_FILE")
+var Webhook_TLS_Client_Auth_Env = os.Getenv("WEBHOOK_TLS_CLIENT_AUTH") // for the separate webhook server
+var Webhook_TLS_Client_CA_File_Env = os.Getenv("WEBHOOK_TLS_CLIENT_CA_FILE")
 var Domain_Env = os.Getenv("DOMAIN")
 var Webhook_Route_Env = os.Getenv("WEBHOOK_ROUTE")
 
-var SessionToken = generateToken(32)
-
 func InitDefaults() {
 	Include_Patterns_Env = slices.DeleteFunc(Include_Patterns_Env, func(s string) bool { return s == "" })
 	Exclude_Patterns_Env = slices.DeleteFunc(Exclude_Patterns_Env, func(s string) bool { return s == "" })
@@ -45,6 +102,9 @@ func InitDefaults() {
 	if Allow_Command_Manipulation_Env == "" {
 		Allow_Command_Manipulation_Env = "true"
 	}
+	if Watch_Config_Files_Env == "" {
+		Watch_Config_Files_Env = "true"
+	}
 	if len(Include_Patterns_Env) == 0 {
 		Include_Patterns_Env = append(Include_Patterns_Env, "**/*")
 	}
@@ -57,33 +117,90 @@ func InitDefaults() {
 	if Password_Env == "" {
 		Password_Env = "admin"
 	}
+	if Session_Idle_Timeout_Env == "" {
+		Session_Idle_Timeout_Env = "24h"
+	}
+	if Session_Max_Age_Env == "" {
+		Session_Max_Age_Env = "168h"
+	}
+	if Login_Max_Attempts_Env == "" {
+		Login_Max_Attempts_Env = "5"
+	}
+	if Login_Lockout_Env == "" {
+		Login_Lockout_Env = "30s"
+	}
+	if Login_Max_Lockout_Env == "" {
+		Login_Max_Lockout_Env = "15m"
+	}
+	if OIDC_Scopes_Env == "" {
+		OIDC_Scopes_Env = "openid profile email"
+	}
+	if OIDC_Username_Claim_Env == "" {
+		OIDC_Username_Claim_Env = "preferred_username"
+	}
+	if OIDC_Groups_Claim_Env == "" {
+		OIDC_Groups_Claim_Env = "groups"
+	}
 	if SSH_Port_Env == "" {
 		SSH_Port_Env = "22"
 	}
+	if SSH_Host_Key_Checking_Env == "" {
+		SSH_Host_Key_Checking_Env = "strict"
+	}
+	if SSH_Keepalive_Interval_Env == "" {
+		SSH_Keepalive_Interval_Env = "30s"
+	}
+	if SSH_Idle_Timeout_Env == "" {
+		SSH_Idle_Timeout_Env = "5m"
+	}
+	if Executor_Env == "" {
+		Executor_Env = "ssh"
+	}
+	if Local_Shell_Env == "" {
+		Local_Shell_Env = "/bin/sh"
+	}
+	if Jobs_History_Limit_Env == "" {
+		Jobs_History_Limit_Env = "200"
+	}
+	if Trusted_Proxy_Hops_Env == "" {
+		Trusted_Proxy_Hops_Env = "1"
+	}
+	if Cookie_Same_Site_Env == "" {
+		Cookie_Same_Site_Env = "lax"
+	}
 	if Port == "" {
 		Port = "8080"
 	}
 	if Webhook_Port == "" {
 		Webhook_Port = Port
 	}
+	if TLS_Client_Auth_Env == "" {
+		TLS_Client_Auth_Env = "off"
+	}
+	if Webhook_TLS_Client_Auth_Env == "" {
+		Webhook_TLS_Client_Auth_Env = "off"
+	}
+	if Webhook_TLS_Cert_File_Env == "" && Webhook_TLS_Key_File_Env == "" {
+		Webhook_TLS_Cert_File_Env = TLS_Cert_File_Env
+		Webhook_TLS_Key_File_Env = TLS_Key_File_Env
+	}
 	if Domain_Env == "" {
-		Domain_Env = "http://127.0.0.1" + ":" + Webhook_Port
+		scheme := "http"
+		if Webhook_TLS_Cert_File_Env != "" {
+			scheme = "https"
+		}
+		Domain_Env = scheme + "://127.0.0.1" + ":" + Webhook_Port
 	}
 	if Webhook_Route_Env == "" {
 		Webhook_Route_Env = "/webhook"
 	}
-	if App_ENV == "development" {
-		SessionToken = "6iKzMBufuKoscBln5mXhcf3p20KMdukI0G234y_DhcE="
-	}
 }
 
-func generateToken(length int) string {
-	// Create a byte slice of the desired length
-	tokenBytes := make([]byte, length)
-	_, err := rand.Read(tokenBytes)
-	if err != nil {
-		return "Raldjlajfdajfdljasldfjjlk"
+// ParseDuration parses a duration env like "30s" or "5m", returns the fallback when invalid or not positive
+func ParseDuration(value string, fallback time.Duration) time.Duration {
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		return fallback
 	}
-
-	return base64.URLEncoding.EncodeToString(tokenBytes)
+	return duration
 }
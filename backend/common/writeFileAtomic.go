@@ -0,0 +1,36 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// WriteFileAtomic writes to a temp file renamed over the file, so a crash or a concurrent reader never sees it half written
+// perm is the mode of a new file, an existing file keeps its mode
+func WriteFileAtomic(filePath string, content []byte, perm os.FileMode) error {
+	if info, err := os.Stat(filePath); err == nil {
+		perm = info.Mode().Perm()
+	}
+
+	tempFile, err := os.CreateTemp(filepath.Dir(filePath), "tmp_"+filepath.Base(filePath))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempFile.Name())
+
+	_, err = tempFile.Write(content)
+	if err == nil {
+		err = tempFile.Chmod(perm)
+	}
+	if err == nil {
+		err = tempFile.Sync()
+	}
+	if closeErr := tempFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tempFile.Name(), filePath)
+}
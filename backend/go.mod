@@ -4,10 +4,17 @@ go 1.24.1
 
 require (
 	github.com/bmatcuk/doublestar v1.3.4
+	github.com/coreos/go-oidc/v3 v3.17.0
+	github.com/creack/pty v1.1.24
+	github.com/fsnotify/fsnotify v1.8.0
+	github.com/google/uuid v1.6.0
+	github.com/gorilla/websocket v1.5.3
 	golang.org/x/crypto v0.37.0
+	golang.org/x/oauth2 v0.28.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
-	github.com/google/uuid v1.6.0
+	github.com/go-jose/go-jose/v4 v4.1.3 // indirect
 	golang.org/x/sys v0.32.0 // indirect
 )
@@ -0,0 +1,61 @@
+package hosts
+
+import (
+	"net"
+	"runny-code/common"
+)
+
+// DefaultHostName is the name of the host built from the `SSH_*` env
+const DefaultHostName = "default"
+
+type Host struct {
+	Name     string `json:"name"`
+	Address  string `json:"address"`
+	Port     string `json:"port"`
+	User     string `json:"user"`
+	Password string `json:"password,omitempty"`
+
+	PrivateKey   string `json:"privateKey,omitempty"` // path to a PEM or OpenSSH key, relative to the config directory
+	Passphrase   string `json:"passphrase,omitempty"` // for encrypted private keys
+	UseAgent     bool   `json:"useAgent,omitempty"`   // authenticate with the keys of the agent at `SSH_AUTH_SOCK`
+	ForwardAgent bool   `json:"forwardAgent,omitempty"`
+}
+
+// HostInfo is the public part of a host, safe to send to the client
+type HostInfo struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+	Port    string `json:"port"`
+	User    string `json:"user"`
+}
+
+var HostEntries []Host
+
+// Addr returns the address to dial, defaults to port 22
+func (h *Host) Addr() string {
+	port := h.Port
+	if port == "" {
+		port = "22"
+	}
+	return net.JoinHostPort(h.Address, port)
+}
+
+func (h *Host) Info() HostInfo {
+	return HostInfo{Name: h.Name, Address: h.Address, Port: h.Port, User: h.User}
+}
+
+// defaultHost builds the host from the `SSH_*` env
+func defaultHost() Host {
+	return Host{
+		Name:     DefaultHostName,
+		Address:  common.SSH_Host_Env,
+		Port:     common.SSH_Port_Env,
+		User:     common.SSH_User_Env,
+		Password: common.SSH_Password_Env,
+
+		PrivateKey:   common.SSH_Private_Key_Env,
+		Passphrase:   common.SSH_Key_Passphrase_Env,
+		UseAgent:     common.SSH_Use_Agent_Env == "true",
+		ForwardAgent: common.SSH_Forward_Agent_Env == "true",
+	}
+}
@@ -0,0 +1,23 @@
+package hosts
+
+import (
+	"os"
+	"runny-code/common"
+)
+
+func CreateFile() error {
+	_, err := os.Stat(common.HostsFile)
+	if os.IsNotExist(err) {
+		file, err := os.Create(common.HostsFile)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		_, err = file.WriteString("[]")
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
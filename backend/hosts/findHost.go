@@ -0,0 +1,10 @@
+package hosts
+
+func FindHost(name string) *Host {
+	for _, host := range HostEntries {
+		if host.Name == name {
+			return &host
+		}
+	}
+	return nil
+}
@@ -0,0 +1,326 @@
+package hosts
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"runny-code/common"
+	"slices"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// Host key checking modes for `SSH_HOST_KEY_CHECKING`
+const (
+	HostKeyCheckingStrict = "strict" // only hosts in known_hosts are accepted
+	HostKeyCheckingTofu   = "tofu"   // unknown hosts are recorded on first use, changed keys are refused
+	HostKeyCheckingOff    = "off"    // no verification, vulnerable to MITM
+)
+
+type KnownHost struct {
+	Hosts       []string `json:"hosts"`
+	Marker      string   `json:"marker"`
+	KeyType     string   `json:"keyType"`
+	Fingerprint string   `json:"fingerprint"`
+	Comment     string   `json:"comment"`
+}
+
+var knownHostsMutex sync.Mutex
+
+func CreateKnownHostsFile() error {
+	_, err := os.Stat(common.KnownHostsFile)
+	if os.IsNotExist(err) {
+		file, err := os.Create(common.KnownHostsFile)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+	}
+	return nil
+}
+
+// HostKeyCallback verifies the server key against known_hosts according to `SSH_HOST_KEY_CHECKING`
+func HostKeyCallback() ssh.HostKeyCallback {
+	if common.SSH_Host_Key_Checking_Env == HostKeyCheckingOff {
+		return ssh.InsecureIgnoreHostKey()
+	}
+
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		knownHostsMutex.Lock()
+		defer knownHostsMutex.Unlock()
+
+		// The file is read on every connection, so edits and revocations apply immediately
+		callback, err := knownhosts.New(common.KnownHostsFile)
+		if err != nil {
+			return fmt.Errorf("failed to read known_hosts: %w", err)
+		}
+
+		err = callback(hostname, remote, key)
+		if err == nil {
+			return nil
+		}
+
+		var keyErr *knownhosts.KeyError
+		if !errors.As(err, &keyErr) {
+			return err
+		}
+
+		fingerprint := ssh.FingerprintSHA256(key)
+
+		// the host is known with a different key
+		if len(keyErr.Want) > 0 {
+			return fmt.Errorf("host key for '%s' has changed (got %s %s), refusing to connect; revoke the stored key if this is expected", hostname, key.Type(), fingerprint)
+		}
+
+		if common.SSH_Host_Key_Checking_Env != HostKeyCheckingTofu {
+			return fmt.Errorf("host '%s' is not in known_hosts (%s %s)", hostname, key.Type(), fingerprint)
+		}
+
+		// trust on first use
+		return appendKnownHost(hostname, key)
+	}
+}
+
+// HostKeyAlgorithms returns the algorithms of the keys known for the address (`host:port`), nil when none is known
+// The server then sends the recorded key rather than its preferred one, which would be refused as changed
+func HostKeyAlgorithms(address string) []string {
+	if common.SSH_Host_Key_Checking_Env == HostKeyCheckingOff {
+		return nil
+	}
+
+	knownHostsMutex.Lock()
+	defer knownHostsMutex.Unlock()
+
+	callback, err := knownhosts.New(common.KnownHostsFile)
+	if err != nil {
+		return nil
+	}
+
+	// no key matches the placeholder, the error lists the known keys of the host
+	var keyErr *knownhosts.KeyError
+	if !errors.As(callback(address, &net.TCPAddr{}, placeholderKey{}), &keyErr) {
+		return nil
+	}
+
+	algorithms := []string{}
+	for _, known := range keyErr.Want {
+		keyAlgorithms := []string{known.Key.Type()}
+		if known.Key.Type() == ssh.KeyAlgoRSA {
+			keyAlgorithms = []string{ssh.KeyAlgoRSASHA512, ssh.KeyAlgoRSASHA256, ssh.KeyAlgoRSA}
+		}
+		for _, algorithm := range keyAlgorithms {
+			if !slices.Contains(algorithms, algorithm) {
+				algorithms = append(algorithms, algorithm)
+			}
+		}
+	}
+	if len(algorithms) == 0 {
+		return nil
+	}
+	return algorithms
+}
+
+// UnknownHosts returns the hosts without a key in known_hosts, strict checking refuses to connect to them
+func UnknownHosts(entries []Host) []Host {
+	unknown := []Host{}
+	for _, host := range entries {
+		if HostKeyAlgorithms(host.Addr()) == nil {
+			unknown = append(unknown, host)
+		}
+	}
+	return unknown
+}
+
+// PrintUnknownHosts explains how to trust the hosts that strict checking would refuse,
+// known_hosts is empty when upgrading from a version without host key checking
+func PrintUnknownHosts() {
+	if common.SSH_Host_Key_Checking_Env != HostKeyCheckingStrict {
+		return
+	}
+
+	unknown := UnknownHosts(HostEntries)
+	for _, host := range unknown {
+		fmt.Fprintf(os.Stderr, "Host '%s' (%s) is not in known_hosts, connections to it are refused with SSH_HOST_KEY_CHECKING=strict\n", host.Name, host.Addr())
+	}
+	if len(unknown) > 0 {
+		fmt.Fprintf(os.Stderr, "To trust the hosts, add their keys to %s (e.g. `ssh-keyscan -p <port> <address> >> config/known_hosts`) or set SSH_HOST_KEY_CHECKING=tofu to record them on first connection\n", common.KnownHostsFile)
+	}
+}
+
+// placeholderKey is a public key that no known_hosts entry matches
+type placeholderKey struct{}
+
+func (placeholderKey) Type() string    { return "placeholder" }
+func (placeholderKey) Marshal() []byte { return []byte("placeholder") }
+func (placeholderKey) Verify(data []byte, sig *ssh.Signature) error {
+	return errors.New("placeholder key")
+}
+
+func appendKnownHost(hostname string, key ssh.PublicKey) error {
+	file, err := os.OpenFile(common.KnownHostsFile, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.WriteString(knownhosts.Line([]string{knownhosts.Normalize(hostname)}, key) + "\n")
+	return err
+}
+
+// ReadKnownHosts lists the keys stored in known_hosts
+func ReadKnownHosts() ([]KnownHost, error) {
+	knownHostsMutex.Lock()
+	defer knownHostsMutex.Unlock()
+
+	lines, err := readKnownHostsLines()
+	if err != nil {
+		return nil, err
+	}
+
+	knownHostsList := []KnownHost{}
+	for _, line := range lines {
+		knownHost, ok := parseKnownHostLine(line)
+		if ok {
+			knownHostsList = append(knownHostsList, knownHost)
+		}
+	}
+
+	return knownHostsList, nil
+}
+
+// RevokeKnownHost removes the stored keys with the given fingerprint
+// If host is not empty only that host is removed, from plain and hashed (`|1|...`) entries,
+// entries listing other hosts too are kept for them
+// Returns the number of changed entries
+func RevokeKnownHost(fingerprint string, host string) (int, error) {
+	knownHostsMutex.Lock()
+	defer knownHostsMutex.Unlock()
+
+	lines, err := readKnownHostsLines()
+	if err != nil {
+		return 0, err
+	}
+
+	if host != "" {
+		host = knownhosts.Normalize(host)
+	}
+	revoked := 0
+	keptLines := []string{}
+	for _, line := range lines {
+		keptLine, changed := revokeFromLine(line, fingerprint, host)
+		if changed {
+			revoked++
+		}
+		if keptLine != "" || !changed {
+			keptLines = append(keptLines, keptLine)
+		}
+	}
+
+	if revoked == 0 {
+		return 0, nil
+	}
+
+	if err := common.WriteFileAtomic(common.KnownHostsFile, []byte(strings.Join(keptLines, "\n")+"\n"), 0600); err != nil {
+		return 0, err
+	}
+
+	return revoked, nil
+}
+
+// revokeFromLine removes the host from a known_hosts line with the fingerprint, or the whole line when host is empty
+// Returns the line to keep, empty when nothing is left of it, and whether it changed
+func revokeFromLine(line string, fingerprint string, host string) (string, bool) {
+	knownHost, ok := parseKnownHostLine(line)
+	if !ok || knownHost.Fingerprint != fingerprint {
+		return line, false
+	}
+	if host == "" {
+		return "", true
+	}
+
+	keptHosts := slices.DeleteFunc(slices.Clone(knownHost.Hosts), func(pattern string) bool {
+		return hostPatternMatches(pattern, host)
+	})
+	if len(keptHosts) == len(knownHost.Hosts) {
+		return line, false
+	}
+	if len(keptHosts) == 0 {
+		return "", true
+	}
+
+	// the hosts are the first field, after the optional marker
+	fields := strings.Fields(line)
+	hostsIndex := 0
+	if strings.HasPrefix(fields[0], "@") {
+		hostsIndex = 1
+	}
+	fields[hostsIndex] = strings.Join(keptHosts, ",")
+	return strings.Join(fields, " "), true
+}
+
+// hostPatternMatches compares a host of a known_hosts line with a normalized host,
+// hashed hosts are `|1|base64(salt)|base64(HMAC-SHA1(salt, host))`
+func hostPatternMatches(pattern string, host string) bool {
+	if !strings.HasPrefix(pattern, "|1|") {
+		return pattern == host
+	}
+
+	parts := strings.Split(pattern[len("|1|"):], "|")
+	if len(parts) != 2 {
+		return false
+	}
+	salt, err := base64.StdEncoding.DecodeString(parts[0])
+	if err != nil {
+		return false
+	}
+	hash, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha1.New, salt)
+	mac.Write([]byte(host))
+	return hmac.Equal(mac.Sum(nil), hash)
+}
+
+func readKnownHostsLines() ([]string, error) {
+	content, err := os.ReadFile(common.KnownHostsFile)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := strings.Split(strings.TrimRight(string(content), "\n"), "\n")
+	if len(lines) == 1 && lines[0] == "" {
+		return []string{}, nil
+	}
+
+	return lines, nil
+}
+
+// parseKnownHostLine parses a single known_hosts line, returns false for comments and invalid lines
+func parseKnownHostLine(line string) (KnownHost, bool) {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+		return KnownHost{}, false
+	}
+
+	marker, hostsList, key, comment, _, err := ssh.ParseKnownHosts([]byte(trimmed))
+	if err != nil {
+		return KnownHost{}, false
+	}
+
+	return KnownHost{
+		Hosts:       hostsList,
+		Marker:      marker,
+		KeyType:     key.Type(),
+		Fingerprint: ssh.FingerprintSHA256(key),
+		Comment:     comment,
+	}, true
+}
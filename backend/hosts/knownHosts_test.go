@@ -0,0 +1,210 @@
+package hosts
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"net"
+	"os"
+	"path/filepath"
+	"runny-code/common"
+	"slices"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+func TestRevokeFromLine(t *testing.T) {
+	key := newTestKey(t)
+	otherKey := newTestKey(t)
+	fingerprint := ssh.FingerprintSHA256(key)
+
+	line := func(hosts ...string) string {
+		return knownhosts.Line(hosts, key)
+	}
+	hashedA := knownhosts.HashHostname("a.example.com")
+
+	tests := []struct {
+		name        string
+		line        string
+		fingerprint string
+		host        string
+		wantLine    string
+		wantChanged bool
+	}{
+		{"whole line without host", line("a.example.com", "b.example.com"), fingerprint, "", "", true},
+		{"other fingerprint", line("a.example.com"), ssh.FingerprintSHA256(otherKey), "a.example.com", line("a.example.com"), false},
+		{"only host", line("a.example.com"), fingerprint, "a.example.com", "", true},
+		{"multi-host line keeps the others", line("a.example.com", "b.example.com"), fingerprint, "a.example.com", line("b.example.com"), true},
+		{"host not on the line", line("b.example.com"), fingerprint, "a.example.com", line("b.example.com"), false},
+		{"hashed host", line(hashedA), fingerprint, "a.example.com", "", true},
+		{"hashed host of another host", line(hashedA), fingerprint, "b.example.com", line(hashedA), false},
+		{"hashed and plain hosts", line(hashedA, "[b.example.com]:2222"), fingerprint, "a.example.com", line("[b.example.com]:2222"), true},
+		{"marker is kept", "@cert-authority " + line("a.example.com", "b.example.com"), fingerprint, "b.example.com", "@cert-authority " + line("a.example.com"), true},
+		{"comment", "# a.example.com", fingerprint, "a.example.com", "# a.example.com", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gotLine, gotChanged := revokeFromLine(test.line, test.fingerprint, test.host)
+			if gotLine != test.wantLine || gotChanged != test.wantChanged {
+				t.Errorf("revokeFromLine() = (%q, %v), want (%q, %v)", gotLine, gotChanged, test.wantLine, test.wantChanged)
+			}
+		})
+	}
+}
+
+func TestHostPatternMatchesHashedHost(t *testing.T) {
+	hashed := knownhosts.HashHostname("[a.example.com]:2222")
+
+	if !hostPatternMatches(hashed, "[a.example.com]:2222") {
+		t.Errorf("hashed pattern does not match its host")
+	}
+	if hostPatternMatches(hashed, "a.example.com") {
+		t.Errorf("hashed pattern matches another host")
+	}
+	if hostPatternMatches("|1|not base64|x", "a.example.com") || hostPatternMatches(strings.TrimSuffix(hashed, "="), "x") {
+		t.Errorf("invalid hashed pattern matches")
+	}
+}
+
+func newTestKey(t *testing.T) ssh.PublicKey {
+	t.Helper()
+
+	publicKey, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ssh.NewPublicKey(publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// useKnownHosts runs the test from a directory whose config has the known_hosts lines, in the checking mode
+func useKnownHosts(t *testing.T, mode string, lines ...string) {
+	root := t.TempDir()
+	for _, dir := range []string{"config", "backend"} {
+		if err := os.Mkdir(filepath.Join(root, dir), 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(filepath.Join(root, "backend"))
+
+	if err := os.WriteFile(common.KnownHostsFile, []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	previous := common.SSH_Host_Key_Checking_Env
+	common.SSH_Host_Key_Checking_Env = mode
+	t.Cleanup(func() { common.SSH_Host_Key_Checking_Env = previous })
+}
+
+func newTestRSASigner(t *testing.T) ssh.Signer {
+	t.Helper()
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer
+}
+
+func TestHostKeyAlgorithms(t *testing.T) {
+	ed25519Key := newTestKey(t)
+	rsaKey := newTestRSASigner(t).PublicKey()
+	useKnownHosts(t, HostKeyCheckingStrict,
+		knownhosts.Line([]string{"a.example.com"}, ed25519Key),
+		knownhosts.Line([]string{knownhosts.HashHostname("[b.example.com]:2222")}, rsaKey),
+		knownhosts.Line([]string{"[b.example.com]:2222"}, ed25519Key),
+	)
+
+	tests := []struct {
+		address string
+		want    []string
+	}{
+		{"a.example.com:22", []string{ssh.KeyAlgoED25519}},
+		{"b.example.com:2222", []string{ssh.KeyAlgoRSASHA512, ssh.KeyAlgoRSASHA256, ssh.KeyAlgoRSA, ssh.KeyAlgoED25519}},
+		{"c.example.com:22", nil},
+	}
+
+	for _, test := range tests {
+		if got := HostKeyAlgorithms(test.address); !slices.Equal(got, test.want) {
+			t.Errorf("HostKeyAlgorithms(%s) = %v, want %v", test.address, got, test.want)
+		}
+	}
+}
+
+func TestStrictCheckingAcceptsServerPreferringAnotherKey(t *testing.T) {
+	_, ed25519Private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ed25519Signer, err := ssh.NewSignerFromKey(ed25519Private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaSigner := newTestRSASigner(t)
+
+	// only the RSA key is known, the server prefers its ed25519 key
+	useKnownHosts(t, HostKeyCheckingStrict, knownhosts.Line([]string{"a.example.com"}, rsaSigner.PublicKey()))
+
+	serverConfig := &ssh.ServerConfig{NoClientAuth: true}
+	serverConfig.AddHostKey(ed25519Signer)
+	serverConfig.AddHostKey(rsaSigner)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		serverConn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer serverConn.Close()
+		ssh.NewServerConn(serverConn, serverConfig)
+	}()
+
+	clientConn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer clientConn.Close()
+
+	config := &ssh.ClientConfig{
+		User:              "runny",
+		HostKeyCallback:   HostKeyCallback(),
+		HostKeyAlgorithms: HostKeyAlgorithms("a.example.com:22"),
+	}
+	conn, _, _, err := ssh.NewClientConn(clientConn, "a.example.com:22", config)
+	if err != nil {
+		t.Fatalf("handshake failed: %s", err)
+	}
+	conn.Close()
+}
+
+func TestUnknownHosts(t *testing.T) {
+	useKnownHosts(t, HostKeyCheckingStrict, knownhosts.Line([]string{"[a.example.com]:2222"}, newTestKey(t)))
+
+	entries := []Host{
+		{Name: "known", Address: "a.example.com", Port: "2222"},
+		{Name: "other port", Address: "a.example.com"},
+		{Name: "unknown", Address: "b.example.com", Port: "22"},
+	}
+	got := []string{}
+	for _, host := range UnknownHosts(entries) {
+		got = append(got, host.Name)
+	}
+	if want := []string{"other port", "unknown"}; !slices.Equal(got, want) {
+		t.Errorf("UnknownHosts() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,40 @@
+package hosts
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"runny-code/common"
+)
+
+// ReadFile reads the hosts inventory
+// Adds the `default` host from the `SSH_*` env when `SSH_HOST` is set and the file does not define it
+func ReadFile() (entries []Host, err error) {
+	file, err := os.Open(common.HostsFile)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	err = json.NewDecoder(file).Decode(&entries)
+	if err != nil {
+		return
+	}
+
+	seen := map[string]bool{}
+	for _, entry := range entries {
+		if entry.Name == "" {
+			return nil, fmt.Errorf("host with address '%s' is missing a name", entry.Address)
+		}
+		if seen[entry.Name] {
+			return nil, fmt.Errorf("host '%s' is defined more than once", entry.Name)
+		}
+		seen[entry.Name] = true
+	}
+
+	if common.SSH_Host_Env != "" && !seen[DefaultHostName] {
+		entries = append(entries, defaultHost())
+	}
+
+	return
+}
@@ -0,0 +1,46 @@
+package identity
+
+import (
+	"context"
+	"net/http"
+	"slices"
+)
+
+// Authentication methods
+const (
+	MethodSession     = "session"
+	MethodToken       = "token"
+	MethodCertificate = "certificate" // a client certificate mapped in the client certs file
+)
+
+// CertificatePrefix starts the username of client certificates mapped to a role only, followed by the matched name
+// so they can not be mistaken for a user, usernames can not start with it
+const CertificatePrefix = "cert:"
+
+// Identity is who made a request, set by the auth middleware
+type Identity struct {
+	Username string `json:"username"`
+	Role     string `json:"role"`
+	Method   string `json:"method"` // how the request was authenticated
+
+	SessionID string   `json:"-"` // set when authenticated with a session cookie
+	TokenID   string   `json:"-"` // set when authenticated with an API token
+	Commands  []string `json:"-"` // commands allowed by the API token, empty for all
+}
+
+type contextKey struct{}
+
+func WithIdentity(ctx context.Context, id Identity) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// AllowsCommand reports whether the API token, if any, allows executing the command
+func (id Identity) AllowsCommand(name string) bool {
+	return len(id.Commands) == 0 || slices.Contains(id.Commands, name)
+}
+
+// FromRequest returns the identity of the request, empty for public routes
+func FromRequest(r *http.Request) Identity {
+	id, _ := r.Context().Value(contextKey{}).(Identity)
+	return id
+}
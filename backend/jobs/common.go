@@ -0,0 +1,132 @@
+package jobs
+
+import (
+	"context"
+	"runny-code/commands"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Job states
+const (
+	StateQueued    = "queued"
+	StateRunning   = "running"
+	StateSucceeded = "succeeded"
+	StateFailed    = "failed"
+	StateCancelled = "cancelled"
+)
+
+// Job sources
+const (
+	SourceUI      = "ui"
+	SourceWebhook = "webhook"
+)
+
+// Event types
+const (
+	EventStdout    = "stdout"
+	EventStderr    = "stderr"
+	EventExit      = "exit"
+	EventError     = "error"
+	EventHeartbeat = "heartbeat"
+	EventReset     = "reset" // the events after the last event id are gone, clients clear the output before the full replay
+)
+
+// maxOutputSize is the maximum captured output per job, older output is dropped
+const maxOutputSize = 1 << 20
+
+// Event is a typed, incremental piece of a job's progress
+// Seq increases by one for every event of a job, heartbeats have no sequence number
+type Event struct {
+	Seq      int    `json:"seq,omitempty"`
+	Type     string `json:"type"`
+	Data     string `json:"data,omitempty"`
+	ExitCode *int   `json:"exitCode,omitempty"`
+	State    string `json:"state,omitempty"`
+}
+
+// Origin describes who started a job
+type Origin struct {
+	Source string
+	Actor  string
+	IP     string
+}
+
+type Job struct {
+	ID           string            `json:"id"`
+	CommandName  string            `json:"commandName"`
+	CommandGroup string            `json:"commandGroup"`
+	Command      string            `json:"command"`
+	Host         string            `json:"host"`
+	Source       string            `json:"source"`
+	Actor        string            `json:"actor"`
+	IP           string            `json:"ip"`
+	Args         map[string]string `json:"args"`
+	State        string            `json:"state"`
+	CreatedAt    time.Time         `json:"createdAt"`
+	StartedAt    *time.Time        `json:"startedAt"`
+	EndedAt      *time.Time        `json:"endedAt"`
+	ExitCode     *int              `json:"exitCode"`
+	Error        string            `json:"error"`
+	Output       string            `json:"output"`
+	LastSeq      int               `json:"lastSeq"` // sequence number of the last event, kept across restarts
+
+	parsedCommand    commands.ParsedCommand
+	executor         commands.Executor
+	commandToExecute string
+	env              map[string]string
+	cancel           context.CancelFunc
+
+	events         []Event
+	eventsSize     int
+	resumableAfter int           // last event ids below it, except 0, lost some events and get a reset
+	notify         chan struct{} // closed and replaced whenever an event is added
+}
+
+// jobsMutex guards jobsList and every field of the jobs in it
+var jobsMutex sync.Mutex
+var jobsList []*Job
+
+func (j *Job) isFinished() bool {
+	return j.State == StateSucceeded || j.State == StateFailed || j.State == StateCancelled
+}
+
+// appendOutput adds a chunk to the output, keeping at most the last `maxOutputSize` bytes
+// The output is cut on a rune boundary so it stays valid UTF-8
+func (j *Job) appendOutput(chunk string) {
+	output := j.Output + chunk
+	if len(output) > maxOutputSize {
+		start := len(output) - maxOutputSize
+		for start < len(output) && !utf8.RuneStart(output[start]) {
+			start++
+		}
+		output = output[start:]
+	}
+	j.Output = output
+}
+
+// addEvent records an event and wakes up the subscribers, must be called while holding jobsMutex
+// The oldest output events are dropped once they exceed `maxOutputSize`
+func (j *Job) addEvent(event Event) {
+	j.LastSeq++
+	event.Seq = j.LastSeq
+	j.events = append(j.events, event)
+	j.eventsSize += len(event.Data)
+
+	for j.eventsSize > maxOutputSize && len(j.events) > 1 {
+		j.eventsSize -= len(j.events[0].Data)
+		j.events = j.events[1:]
+		j.resumableAfter = max(j.resumableAfter, j.events[0].Seq-1)
+	}
+
+	if j.notify != nil {
+		close(j.notify)
+	}
+	j.notify = make(chan struct{})
+}
+
+// addExitEvent records the final state of the job, must be called while holding jobsMutex
+func (j *Job) addExitEvent() {
+	j.addEvent(Event{Type: EventExit, ExitCode: j.ExitCode, State: j.State})
+}
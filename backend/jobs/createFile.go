@@ -0,0 +1,28 @@
+package jobs
+
+import (
+	"os"
+	"runny-code/common"
+)
+
+func CreateFile() error {
+	err := os.MkdirAll(common.JobsOutputDir, 0700)
+	if err != nil {
+		return err
+	}
+
+	_, err = os.Stat(common.JobsFile)
+	if os.IsNotExist(err) {
+		file, err := os.Create(common.JobsFile)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		_, err = file.WriteString("[]")
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package jobs
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"runny-code/commands"
+	"runny-code/common"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const maskedValue = "********"
+
+// ErrSaveFailed is returned by Create when the jobs history can not be written, the other errors are about the arguments
+var ErrSaveFailed = errors.New("failed to save the job")
+
+// Create fills the command with the arguments and registers a queued job
+// Arguments of `password` variables are masked in the stored job
+func Create(parsedCommand *commands.ParsedCommand, origin Origin, hostName string, args map[string]string) (*Job, error) {
+	commandToExecute, err := commands.FillCommand(parsedCommand.Command, parsedCommand.Variables, args)
+	if err != nil {
+		return nil, err
+	}
+
+	executor, err := commands.GetExecutor(parsedCommand, hostName)
+	if err != nil {
+		return nil, err
+	}
+
+	maskedArgs := maps.Clone(args)
+	if maskedArgs == nil {
+		maskedArgs = map[string]string{}
+	}
+	for _, variable := range parsedCommand.Variables {
+		if _, ok := maskedArgs[variable.Name]; ok && variable.Type == "password" {
+			maskedArgs[variable.Name] = maskedValue
+		}
+	}
+
+	job := &Job{
+		ID:           uuid.New().String(),
+		CommandName:  parsedCommand.Name,
+		CommandGroup: parsedCommand.Group,
+		Command:      parsedCommand.Command,
+		Host:         hostName,
+		Source:       origin.Source,
+		Actor:        origin.Actor,
+		IP:           origin.IP,
+		Args:         maskedArgs,
+		State:        StateQueued,
+		CreatedAt:    time.Now(),
+
+		parsedCommand:    *parsedCommand,
+		executor:         executor,
+		commandToExecute: commandToExecute,
+		env:              commands.CommandEnv(parsedCommand),
+	}
+
+	jobsMutex.Lock()
+	defer jobsMutex.Unlock()
+
+	jobsList = append(jobsList, job)
+	pruneHistory()
+
+	// a job that is not saved is never started, it would stay queued
+	if err := writeToFile(); err != nil {
+		jobsList = slices.DeleteFunc(jobsList, func(entry *Job) bool { return entry == job })
+		return nil, fmt.Errorf("%w: %w", ErrSaveFailed, err)
+	}
+	return job, nil
+}
+
+// pruneHistory removes the oldest finished jobs above `JOBS_HISTORY_LIMIT`
+func pruneHistory() {
+	limit, err := strconv.Atoi(common.Jobs_History_Limit_Env)
+	if err != nil || limit <= 0 {
+		limit = 200
+	}
+
+	for len(jobsList) > limit {
+		index := slices.IndexFunc(jobsList, (*Job).isFinished)
+		if index == -1 {
+			return
+		}
+
+		if err := removeOutputFile(jobsList[index].ID); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to remove the output of job %s: %s\n", jobsList[index].ID, err)
+		}
+		jobsList = slices.Delete(jobsList, index, index+1)
+	}
+}
@@ -0,0 +1,20 @@
+package jobs
+
+import (
+	"errors"
+	"runny-code/commands"
+	"testing"
+)
+
+func TestCreateDropsJobThatCannotBeSaved(t *testing.T) {
+	useConfigDir(t, false)
+
+	parsedCommand := &commands.ParsedCommand{Name: "Hello", Command: "echo hello", Executor: commands.ExecutorLocal}
+	job, err := Create(parsedCommand, Origin{Source: SourceUI}, "", nil)
+	if !errors.Is(err, ErrSaveFailed) {
+		t.Fatalf("got error %v, want %v", err, ErrSaveFailed)
+	}
+	if job != nil || len(List()) != 0 {
+		t.Errorf("got job %v and %d jobs in the history, want the job dropped", job, len(List()))
+	}
+}
@@ -0,0 +1,91 @@
+package jobs
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"time"
+)
+
+// Get returns a copy of the job
+func Get(id string) (Job, bool) {
+	jobsMutex.Lock()
+	defer jobsMutex.Unlock()
+
+	for _, job := range jobsList {
+		if job.ID == id {
+			return *job, true
+		}
+	}
+	return Job{}, false
+}
+
+// List returns copies of all jobs without their output, newest first
+func List() []Job {
+	jobsMutex.Lock()
+	defer jobsMutex.Unlock()
+
+	list := make([]Job, 0, len(jobsList))
+	for _, job := range slices.Backward(jobsList) {
+		jobCopy := *job
+		jobCopy.Output = ""
+		list = append(list, jobCopy)
+	}
+	return list
+}
+
+// Cancel stops a queued or running job, returns false if the job is not found or already finished
+func Cancel(id string) bool {
+	jobsMutex.Lock()
+	defer jobsMutex.Unlock()
+
+	for _, job := range jobsList {
+		if job.ID != id {
+			continue
+		}
+
+		switch job.State {
+		case StateQueued:
+			now := time.Now()
+			job.State = StateCancelled
+			job.EndedAt = &now
+			job.addExitEvent()
+			saveFinished(job)
+			auditJob(job)
+			return true
+		case StateRunning:
+			if job.cancel != nil {
+				job.cancel()
+			}
+			return true
+		}
+		return false
+	}
+	return false
+}
+
+// Delete removes a finished job from the history
+func Delete(id string) error {
+	jobsMutex.Lock()
+	defer jobsMutex.Unlock()
+
+	index := slices.IndexFunc(jobsList, func(job *Job) bool { return job.ID == id })
+	if index == -1 {
+		return fmt.Errorf("job '%s' not found", id)
+	}
+
+	if !jobsList[index].isFinished() {
+		return fmt.Errorf("job '%s' is still %s", id, jobsList[index].State)
+	}
+
+	jobsList = slices.Delete(jobsList, index, index+1)
+	err := writeToFile()
+	if err != nil {
+		return err
+	}
+
+	if err := removeOutputFile(id); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to remove the output of job %s: %s\n", id, err)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package jobs
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runny-code/common"
+)
+
+// outputFilePath returns the file storing the output of a job
+func outputFilePath(id string) string {
+	return filepath.Join(common.JobsOutputDir, id+".log")
+}
+
+// writeOutputFile persists the output of a finished job, jobs without output have no file
+// Must be called while holding jobsMutex
+func writeOutputFile(job *Job) error {
+	if job.Output == "" {
+		return nil
+	}
+
+	err := os.MkdirAll(common.JobsOutputDir, 0700)
+	if err != nil {
+		return err
+	}
+
+	return common.WriteFileAtomic(outputFilePath(job.ID), []byte(job.Output), 0600)
+}
+
+// readOutputFile returns the persisted output of a job, empty if it has none
+func readOutputFile(id string) (string, error) {
+	output, err := os.ReadFile(outputFilePath(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	return string(output), err
+}
+
+// removeOutputFile deletes the output of a removed job
+func removeOutputFile(id string) error {
+	err := os.Remove(outputFilePath(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,46 @@
+package jobs
+
+import (
+	"context"
+	"os"
+	"runny-code/common"
+	"strings"
+	"testing"
+)
+
+func TestOutputIsStoredOutsideTheHistory(t *testing.T) {
+	useConfigDir(t, true)
+	job := queueJob(streamExecutor{chunks: []string{"secret output"}})
+
+	if err := Run(context.Background(), job, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	history, err := os.ReadFile(common.JobsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(history), "secret output") {
+		t.Errorf("the history file contains the output: %s", history)
+	}
+
+	output, err := os.ReadFile(outputFilePath(job.ID))
+	if err != nil || string(output) != "secret output" {
+		t.Fatalf("output file is %q (%v), want the job output", output, err)
+	}
+
+	if err := ReadFile(); err != nil {
+		t.Fatal(err)
+	}
+	restored, ok := Get(job.ID)
+	if !ok || restored.Output != "secret output" {
+		t.Fatalf("restored job has output %q, want it read from its file", restored.Output)
+	}
+
+	if err := Delete(job.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(outputFilePath(job.ID)); !os.IsNotExist(err) {
+		t.Errorf("output file still exists after deleting the job: %v", err)
+	}
+}
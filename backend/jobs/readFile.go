@@ -0,0 +1,81 @@
+package jobs
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"runny-code/common"
+	"time"
+)
+
+// ReadFile loads the jobs history and stores it
+// Jobs that were still queued or running when the server stopped are marked as failed
+func ReadFile() (err error) {
+	file, err := os.Open(common.JobsFile)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	entries := []*Job{}
+	err = json.NewDecoder(file).Decode(&entries)
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+	for _, job := range entries {
+		if job.Output, err = readOutputFile(job.ID); err != nil {
+			return
+		}
+
+		interrupted := !job.isFinished()
+		if interrupted {
+			job.State = StateFailed
+			job.Error = "interrupted by a server restart"
+			job.EndedAt = &now
+		}
+
+		job.restoreEvents(interrupted)
+	}
+
+	jobsMutex.Lock()
+	defer jobsMutex.Unlock()
+	jobsList = entries
+
+	return nil
+}
+
+// restoreEvents rebuilds the events of a loaded job from its captured output, events are not persisted
+// The rebuilt events end on the persisted last sequence number, the output becomes a single event
+// so only clients that saw all of it resume exactly, the others get a reset and a full replay
+func (j *Job) restoreEvents(interrupted bool) {
+	hasOutput := j.Output != ""
+	hasError := j.Error != "" && j.ExitCode == nil
+
+	count := 1
+	if hasOutput {
+		count++
+	}
+	if hasError {
+		count++
+	}
+
+	// the sequence numbers of a job that was still running were never saved
+	lastSeq := j.LastSeq
+	j.LastSeq = 0
+	j.resumableAfter = math.MaxInt
+	if !interrupted && lastSeq >= count {
+		j.LastSeq = lastSeq - count
+		j.resumableAfter = 0
+	}
+
+	if hasOutput {
+		j.addEvent(Event{Type: EventStdout, Data: j.Output})
+		j.resumableAfter = max(j.resumableAfter, j.LastSeq)
+	}
+	if hasError {
+		j.addEvent(Event{Type: EventError, Data: j.Error})
+	}
+	j.addExitEvent()
+}
@@ -0,0 +1,206 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"runny-code/audit"
+	"runny-code/commands"
+	"strconv"
+	"time"
+)
+
+var errCancelledBeforeStart = errors.New("job was cancelled before it started")
+
+// Start runs a queued job in the background, it keeps running when the client disconnects
+func Start(job *Job) {
+	go Run(context.Background(), job, nil)
+}
+
+// Run executes a queued job and blocks until it finishes
+// The job is stopped when the parent context is done, on timeout or when cancelled
+// outputHandler receives each new chunk of output while running and may be nil
+func Run(parent context.Context, job *Job, outputHandler func(stdout string, stderr string)) error {
+	ctx, cancel := commands.WithTimeout(parent, &job.parsedCommand)
+	defer cancel()
+
+	if err := begin(job, cancel); err != nil {
+		reject(job, err)
+		return err
+	}
+
+	err := job.executor.ExecuteStream(ctx, job.commandToExecute, job.env, func(stdout string, stderr string) {
+		jobsMutex.Lock()
+		if stdout != "" {
+			job.appendOutput(stdout)
+			job.addEvent(Event{Type: EventStdout, Data: stdout})
+		}
+		if stderr != "" {
+			job.appendOutput(stderr)
+			job.addEvent(Event{Type: EventStderr, Data: stderr})
+		}
+		jobsMutex.Unlock()
+
+		if outputHandler != nil {
+			outputHandler(stdout, stderr)
+		}
+	})
+
+	finish(ctx, job, err)
+	return err
+}
+
+// RunInteractive executes a queued job attached to a pseudo terminal and blocks until it finishes
+// The terminal output is recorded as stdout since a pty merges both streams
+func RunInteractive(parent context.Context, job *Job, terminal commands.Terminal) error {
+	interactiveExecutor, ok := job.executor.(commands.InteractiveExecutor)
+	if !ok {
+		err := errors.New("the executor of this command does not support tty mode")
+		reject(job, err)
+		return err
+	}
+
+	ctx, cancel := commands.WithTimeout(parent, &job.parsedCommand)
+	defer cancel()
+
+	if err := begin(job, cancel); err != nil {
+		reject(job, err)
+		return err
+	}
+
+	output := terminal.Output
+	terminal.Output = writerFunc(func(p []byte) (int, error) {
+		jobsMutex.Lock()
+		job.appendOutput(string(p))
+		job.addEvent(Event{Type: EventStdout, Data: string(p)})
+		jobsMutex.Unlock()
+
+		return output.Write(p)
+	})
+
+	err := interactiveExecutor.ExecuteInteractive(ctx, job.commandToExecute, job.env, terminal)
+
+	finish(ctx, job, err)
+	return err
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) {
+	return f(p)
+}
+
+// begin marks a queued job as running, the job stays queued when it cannot be persisted
+func begin(job *Job, cancel context.CancelFunc) error {
+	jobsMutex.Lock()
+	defer jobsMutex.Unlock()
+
+	if job.State != StateQueued {
+		return errCancelledBeforeStart
+	}
+
+	now := time.Now()
+	job.State = StateRunning
+	job.StartedAt = &now
+	job.cancel = cancel
+
+	if err := writeToFile(); err != nil {
+		job.State = StateQueued
+		job.StartedAt = nil
+		job.cancel = nil
+		return err
+	}
+	return nil
+}
+
+// reject marks a job that could not be started as failed, it does nothing if the job already left the queue
+func reject(job *Job, err error) {
+	jobsMutex.Lock()
+	defer jobsMutex.Unlock()
+
+	if job.State != StateQueued {
+		return
+	}
+
+	now := time.Now()
+	job.State = StateFailed
+	job.EndedAt = &now
+	job.Error = err.Error()
+	job.addEvent(Event{Type: EventError, Data: err.Error()})
+	job.addExitEvent()
+
+	saveFinished(job)
+	auditJob(job)
+}
+
+// finish records the result of a job and persists it
+func finish(ctx context.Context, job *Job, err error) {
+	jobsMutex.Lock()
+	defer jobsMutex.Unlock()
+
+	now := time.Now()
+	job.EndedAt = &now
+	job.cancel = nil
+
+	if exitCode := commands.ExitCode(err); exitCode >= 0 {
+		job.ExitCode = &exitCode
+	}
+
+	switch {
+	case err == nil:
+		job.State = StateSucceeded
+	case errors.Is(ctx.Err(), context.Canceled):
+		job.State = StateCancelled
+		job.Error = err.Error()
+	default:
+		job.State = StateFailed
+		job.Error = err.Error()
+	}
+
+	// the command did not exit on its own, explain why
+	if err != nil && job.ExitCode == nil {
+		job.addEvent(Event{Type: EventError, Data: err.Error()})
+	}
+	job.addExitEvent()
+
+	saveFinished(job)
+	auditJob(job)
+}
+
+// saveFinished persists a job that just finished, must be called while holding jobsMutex
+// Failures are only logged since the job already ended and is kept in memory
+func saveFinished(job *Job) {
+	if err := writeOutputFile(job); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to save the output of job %s: %s\n", job.ID, err)
+	}
+	if err := writeToFile(); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to save the jobs: %s\n", err)
+	}
+}
+
+// auditJob logs the result of a finished job, must be called while holding jobsMutex
+func auditJob(job *Job) {
+	entry := audit.Entry{
+		Actor:  job.Actor,
+		IP:     job.IP,
+		Action: "command.execute",
+		Target: job.CommandName,
+		Result: audit.ResultSuccess,
+		Error:  job.Error,
+		Details: map[string]string{
+			"jobId":  job.ID,
+			"source": job.Source,
+			"host":   job.Host,
+			"state":  job.State,
+		},
+	}
+	if job.State != StateSucceeded {
+		entry.Result = audit.ResultFailure
+	}
+	if job.ExitCode != nil {
+		entry.Details["exitCode"] = strconv.Itoa(*job.ExitCode)
+	}
+
+	audit.Log(entry)
+}
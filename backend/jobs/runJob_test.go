@@ -0,0 +1,120 @@
+package jobs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runny-code/commands"
+	"strings"
+	"testing"
+	"time"
+)
+
+// streamExecutor outputs fixed chunks and does not support tty mode
+type streamExecutor struct {
+	chunks []string
+}
+
+func (e streamExecutor) Execute(ctx context.Context, command string, env map[string]string) ([]byte, error) {
+	return []byte(strings.Join(e.chunks, "")), nil
+}
+
+func (e streamExecutor) ExecuteStream(ctx context.Context, command string, env map[string]string, outputHandler func(stdout string, stderr string)) error {
+	for _, chunk := range e.chunks {
+		outputHandler(chunk, "")
+	}
+	return nil
+}
+
+// useConfigDir runs the test from a directory whose `../config` exists when withConfig is true
+func useConfigDir(t *testing.T, withConfig bool) {
+	root := t.TempDir()
+	if withConfig {
+		if err := os.Mkdir(filepath.Join(root, "config"), 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	workDir := filepath.Join(root, "backend")
+	if err := os.Mkdir(workDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(workDir)
+
+	jobsMutex.Lock()
+	previous := jobsList
+	jobsList = nil
+	jobsMutex.Unlock()
+
+	t.Cleanup(func() {
+		jobsMutex.Lock()
+		jobsList = previous
+		jobsMutex.Unlock()
+	})
+}
+
+func queueJob(executor commands.Executor) *Job {
+	job := &Job{
+		ID:        "job-" + time.Now().Format("150405.000000000"),
+		State:     StateQueued,
+		CreatedAt: time.Now(),
+		executor:  executor,
+	}
+
+	jobsMutex.Lock()
+	jobsList = append(jobsList, job)
+	jobsMutex.Unlock()
+	return job
+}
+
+func TestRunFailsJobThatCannotBePersisted(t *testing.T) {
+	useConfigDir(t, false)
+	job := queueJob(streamExecutor{chunks: []string{"hello"}})
+
+	_, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := begin(job, cancel); err == nil {
+		t.Fatal("expected begin to fail without a config directory")
+	}
+	if job.State != StateQueued || job.StartedAt != nil || job.cancel != nil {
+		t.Fatalf("begin left the job %s with startedAt %v, want it untouched", job.State, job.StartedAt)
+	}
+
+	if err := Run(context.Background(), job, nil); err == nil {
+		t.Fatal("expected Run to fail without a config directory")
+	}
+	if job.State != StateFailed || job.EndedAt == nil || job.Error == "" {
+		t.Errorf("job is %s with error %q, want failed", job.State, job.Error)
+	}
+	if job.Output != "" {
+		t.Errorf("job output is %q, want the command not to run", job.Output)
+	}
+}
+
+func TestRunInteractiveFailsJobWithoutTtySupport(t *testing.T) {
+	useConfigDir(t, true)
+	job := queueJob(streamExecutor{chunks: []string{"hello"}})
+
+	if err := RunInteractive(context.Background(), job, commands.Terminal{}); err == nil {
+		t.Fatal("expected RunInteractive to fail")
+	}
+	if job.State != StateFailed || job.EndedAt == nil {
+		t.Fatalf("job is %s, want failed", job.State)
+	}
+
+	last := job.events[len(job.events)-1]
+	if last.Type != EventExit || last.State != StateFailed {
+		t.Errorf("last event is %s with state %s, want an exit event with the failed state", last.Type, last.State)
+	}
+}
+
+func TestRunRecordsOutput(t *testing.T) {
+	useConfigDir(t, true)
+	job := queueJob(streamExecutor{chunks: []string{"hello"}})
+
+	if err := Run(context.Background(), job, nil); err != nil {
+		t.Fatal(err)
+	}
+	if job.State != StateSucceeded || job.Output != "hello" {
+		t.Errorf("job is %s with output %q, want succeeded with the output", job.State, job.Output)
+	}
+}
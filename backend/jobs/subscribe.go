@@ -0,0 +1,85 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrJobNotFound = errors.New("job not found")
+
+// Subscribe sends the events of a job that come after lastSeq, then follows new events until the job finishes
+// A reset event and a full replay are sent instead when some events after lastSeq are not available anymore
+// A heartbeat event is sent whenever no event was sent for the heartbeat interval
+// Returns when the job finished and all its events were sent, the context is done or send fails
+func Subscribe(ctx context.Context, id string, lastSeq int, heartbeat time.Duration, send func(Event) error) error {
+	job := findJob(id)
+	if job == nil {
+		return ErrJobNotFound
+	}
+
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+
+	for {
+		jobsMutex.Lock()
+		reset := lastSeq > 0 && lastSeq < job.resumableAfter
+		if reset {
+			lastSeq = 0
+		}
+		pending := []Event{}
+		for _, event := range job.events {
+			if event.Seq > lastSeq {
+				pending = append(pending, event)
+			}
+		}
+		finished := job.isFinished()
+		if job.notify == nil {
+			job.notify = make(chan struct{})
+		}
+		notify := job.notify
+		jobsMutex.Unlock()
+
+		if reset {
+			if err := send(Event{Type: EventReset}); err != nil {
+				return err
+			}
+		}
+		for _, event := range pending {
+			if err := send(event); err != nil {
+				return err
+			}
+			lastSeq = event.Seq
+		}
+		if len(pending) > 0 {
+			ticker.Reset(heartbeat)
+		}
+
+		// the exit event is always the last one
+		if finished {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-notify:
+		case <-ticker.C:
+			if err := send(Event{Type: EventHeartbeat}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func findJob(id string) *Job {
+	jobsMutex.Lock()
+	defer jobsMutex.Unlock()
+
+	for _, job := range jobsList {
+		if job.ID == id {
+			return job
+		}
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package jobs
+
+import (
+	"context"
+	"os"
+	"runny-code/common"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+// collectEvents returns the types of the events sent to a subscriber resuming after lastSeq
+func collectEvents(t *testing.T, id string, lastSeq int) []string {
+	types := []string{}
+	err := Subscribe(context.Background(), id, lastSeq, time.Minute, func(event Event) error {
+		types = append(types, event.Type)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return types
+}
+
+func TestAppendOutputKeepsRunesWhole(t *testing.T) {
+	job := &Job{}
+	job.appendOutput(strings.Repeat("é", maxOutputSize/2))
+	job.appendOutput("x")
+
+	if !utf8.ValidString(job.Output) {
+		t.Error("trimmed output is not valid UTF-8")
+	}
+	if len(job.Output) > maxOutputSize {
+		t.Errorf("output has %d bytes, want at most %d", len(job.Output), maxOutputSize)
+	}
+	if !strings.HasSuffix(job.Output, "éx") {
+		t.Error("trimmed output lost its end")
+	}
+}
+
+func TestSubscribeResumesAfterRestart(t *testing.T) {
+	useConfigDir(t, true)
+	job := queueJob(streamExecutor{chunks: []string{"hel", "lo"}})
+	if err := Run(context.Background(), job, nil); err != nil {
+		t.Fatal(err)
+	}
+	lastSeq := job.LastSeq
+
+	if err := ReadFile(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		lastSeq int
+		want    string
+	}{
+		{"new subscriber", 0, "stdout exit"},
+		{"saw all the output", lastSeq - 1, "exit"},
+		{"saw everything", lastSeq, ""},
+		{"saw part of the output", lastSeq - 2, "reset stdout exit"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := strings.Join(collectEvents(t, job.ID, test.lastSeq), " ")
+			if got != test.want {
+				t.Errorf("got events %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSubscribeResetsInterruptedJob(t *testing.T) {
+	useConfigDir(t, true)
+
+	running := `[{"id":"running","state":"running","lastSeq":0}]`
+	if err := os.WriteFile(common.JobsFile, []byte(running), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ReadFile(); err != nil {
+		t.Fatal(err)
+	}
+
+	got := strings.Join(collectEvents(t, "running", 42), " ")
+	if got != "reset error exit" {
+		t.Errorf("got events %q, want a reset and the full replay", got)
+	}
+}
+
+func TestSubscribeResetsAfterDroppedEvents(t *testing.T) {
+	useConfigDir(t, true)
+	job := queueJob(streamExecutor{})
+
+	jobsMutex.Lock()
+	for range 3 {
+		job.addEvent(Event{Type: EventStdout, Data: strings.Repeat("x", maxOutputSize/2+1)})
+	}
+	job.State = StateSucceeded
+	job.addExitEvent()
+	jobsMutex.Unlock()
+
+	if got := strings.Join(collectEvents(t, job.ID, 1), " "); got != "reset stdout exit" {
+		t.Errorf("got events %q, want a reset and the kept events", got)
+	}
+	if got := strings.Join(collectEvents(t, job.ID, 3), " "); got != "exit" {
+		t.Errorf("got events %q, want only the events after the last id", got)
+	}
+}
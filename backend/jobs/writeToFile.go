@@ -0,0 +1,24 @@
+package jobs
+
+import (
+	"encoding/json"
+	"runny-code/common"
+)
+
+// writeToFile persists the jobs history, must be called while holding jobsMutex
+// The output of the jobs is not part of it, see writeOutputFile
+func writeToFile() (err error) {
+	entries := make([]Job, 0, len(jobsList))
+	for _, job := range jobsList {
+		entry := *job
+		entry.Output = ""
+		entries = append(entries, entry)
+	}
+
+	jsonBytes, err := json.Marshal(entries)
+	if err != nil {
+		return
+	}
+
+	return common.WriteFileAtomic(common.JobsFile, jsonBytes, 0600)
+}
@@ -1,9 +1,21 @@
 package main
 
 import (
+	"os"
 	"runny-code/api"
+	"runny-code/cli"
+	"runny-code/clientcerts"
 	"runny-code/commands"
 	"runny-code/common"
+	"runny-code/hosts"
+	"runny-code/jobs"
+	"runny-code/roles"
+	"runny-code/sessions"
+	"runny-code/settings"
+	"runny-code/sso"
+	"runny-code/tokens"
+	"runny-code/users"
+	"runny-code/watcher"
 	"runny-code/webhooks"
 )
 
@@ -11,11 +23,22 @@ func main() {
 	// init defaults
 	common.InitDefaults()
 
-	// create commands file
-	err := commands.CreateCommandsTxtFile(common.CommandsFile)
+	// subcommands like `runny-code convert` run instead of the server
+	if len(os.Args) > 1 {
+		os.Exit(cli.Run(os.Args[1:]))
+	}
+
+	// create commands file, unless the commands are in a YAML or JSON catalog
+	hasCatalog, err := commands.HasCatalogFile()
 	if err != nil {
 		panic(err)
 	}
+	if !hasCatalog {
+		err = commands.CreateCommandsTxtFile(common.CommandsFile)
+		if err != nil {
+			panic(err)
+		}
+	}
 
 	// create webhooks file
 	err = webhooks.CreateFile()
@@ -23,19 +46,144 @@ func main() {
 		panic(err)
 	}
 
+	// create settings file
+	err = settings.CreateFile()
+	if err != nil {
+		panic(err)
+	}
+
+	// load the settings
+	err = settings.ReadFile()
+	if err != nil {
+		panic(err)
+	}
+
+	// create users file with the bootstrap admin
+	err = users.CreateFile()
+	if err != nil {
+		panic(err)
+	}
+
+	// load the users
+	err = users.ReadFile()
+	if err != nil {
+		panic(err)
+	}
+
+	// create roles file
+	err = roles.CreateFile()
+	if err != nil {
+		panic(err)
+	}
+
+	// parse the rules of the roles and store them
+	rolesRules, err := roles.ReadFile()
+	if err != nil {
+		panic(err)
+	}
+	roles.RolesRules = rolesRules
+
+	// create sessions file
+	err = sessions.CreateFile()
+	if err != nil {
+		panic(err)
+	}
+
+	// load the sessions that are still valid
+	err = sessions.ReadFile()
+	if err != nil {
+		panic(err)
+	}
+
+	// create API tokens file
+	err = tokens.CreateFile()
+	if err != nil {
+		panic(err)
+	}
+
+	// load the API tokens
+	err = tokens.ReadFile()
+	if err != nil {
+		panic(err)
+	}
+
+	// create client certificates file
+	err = clientcerts.CreateFile()
+	if err != nil {
+		panic(err)
+	}
+
+	// load the client certificate mappings
+	err = clientcerts.ReadFile()
+	if err != nil {
+		panic(err)
+	}
+
+	// the bootstrap credentials are public, refuse to run with them in production when asked to
+	if common.Refuse_Default_Credentials_Env == "true" && common.App_ENV == "production" {
+		if _, err := users.Authenticate("admin", "admin"); err == nil {
+			panic("refusing to start: the 'admin' user still has the default password, change it or set REFUSE_DEFAULT_CREDENTIALS=false")
+		}
+	}
+
+	// check the single sign-on config
+	err = sso.CheckConfig()
+	if err != nil {
+		panic(err)
+	}
+
+	// create hosts file
+	err = hosts.CreateFile()
+	if err != nil {
+		panic(err)
+	}
+
+	// create known_hosts file
+	err = hosts.CreateKnownHostsFile()
+	if err != nil {
+		panic(err)
+	}
+
+	// parse hosts and store them
+	hostsList, err := hosts.ReadFile()
+	if err != nil {
+		panic(err)
+	}
+	hosts.HostEntries = hostsList
+	hosts.PrintUnknownHosts()
+
 	// parse commands and store them
-	commandsList, err := commands.ParseCommands(common.CommandsFile)
+	err = commands.Reload()
 	if err != nil {
 		panic(err)
 	}
-	commands.ParsedCommandsList = commandsList
+	commands.PrintFindings()
 
 	// parse webhooks and store them
-	webhooksList, err := webhooks.ReadFile()
+	err = webhooks.Load()
+	if err != nil {
+		panic(err)
+	}
+
+	// reload the commands and webhooks when their files are edited on disk
+	if common.Watch_Config_Files_Env == "true" {
+		err = watcher.Start()
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	// create jobs file
+	err = jobs.CreateFile()
+	if err != nil {
+		panic(err)
+	}
+
+	// load the jobs history
+	err = jobs.ReadFile()
 	if err != nil {
 		panic(err)
 	}
-	webhooks.WebhookEntries = webhooksList
 
 	err = api.InitServer()
 	if err != nil {
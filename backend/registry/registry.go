@@ -0,0 +1,57 @@
+package registry
+
+import (
+	"reflect"
+	"sync"
+)
+
+// Registry holds a list shared by the handlers as copy-on-write snapshots
+// Readers get the current list without waiting for writers, it is replaced as a whole and never modified in place
+// Writers are serialized, so a change can check the current list, persist the new one and store it without a concurrent change in between
+type Registry[T any] struct {
+	writeMutex sync.Mutex   // held by writers for the whole change, including persisting it
+	mutex      sync.RWMutex // guards items and version
+	items      []T
+	version    uint64
+}
+
+// Snapshot returns the current list and its version, the list must not be modified
+func (r *Registry[T]) Snapshot() ([]T, uint64) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.items, r.version
+}
+
+// List returns the current list, it must not be modified
+func (r *Registry[T]) List() []T {
+	items, _ := r.Snapshot()
+	return items
+}
+
+// Version is incremented each time the list changes
+func (r *Registry[T]) Version() uint64 {
+	_, version := r.Snapshot()
+	return version
+}
+
+// Update calls change with the current list and stores the list it returns, unless it returns an error
+// The version is only incremented when the new list differs, changed reports whether it did
+func (r *Registry[T]) Update(change func(current []T) ([]T, error)) (version uint64, changed bool, err error) {
+	r.writeMutex.Lock()
+	defer r.writeMutex.Unlock()
+
+	current, version := r.Snapshot()
+	items, err := change(current)
+	if err != nil {
+		return version, false, err
+	}
+	if reflect.DeepEqual(items, current) {
+		return version, false, nil
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.items = items
+	r.version++
+	return r.version, true, nil
+}
@@ -0,0 +1,78 @@
+package registry
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestUpdate(t *testing.T) {
+	registry := Registry[string]{}
+	failure := errors.New("refused")
+
+	tests := []struct {
+		name        string
+		change      func(current []string) ([]string, error)
+		want        []string
+		wantVersion uint64
+		wantChanged bool
+		wantErr     error
+	}{
+		{"add", func(current []string) ([]string, error) { return append(slices.Clone(current), "a"), nil }, []string{"a"}, 1, true, nil},
+		{"same list", func(current []string) ([]string, error) { return []string{"a"}, nil }, []string{"a"}, 1, false, nil},
+		{"error keeps the list", func(current []string) ([]string, error) { return []string{"b"}, failure }, []string{"a"}, 1, false, failure},
+		{"replace", func(current []string) ([]string, error) { return []string{"b"}, nil }, []string{"b"}, 2, true, nil},
+	}
+
+	for _, test := range tests {
+		version, changed, err := registry.Update(test.change)
+		if !errors.Is(err, test.wantErr) || changed != test.wantChanged || version != test.wantVersion {
+			t.Errorf("%s: got version %d changed %t error %v, want %d %t %v", test.name, version, changed, err, test.wantVersion, test.wantChanged, test.wantErr)
+		}
+
+		items, version := registry.Snapshot()
+		if !slices.Equal(items, test.want) || version != test.wantVersion {
+			t.Errorf("%s: got %q at version %d, want %q at %d", test.name, items, version, test.want, test.wantVersion)
+		}
+	}
+}
+
+// TestConcurrentUpdates is meant to run with -race, writers must not lose changes and readers always see a whole list
+func TestConcurrentUpdates(t *testing.T) {
+	registry := Registry[int]{}
+
+	const writers, updates = 8, 50
+	wait := sync.WaitGroup{}
+	for range writers {
+		wait.Add(2)
+		go func() {
+			defer wait.Done()
+			for range updates {
+				registry.Update(func(current []int) ([]int, error) {
+					return append(slices.Clone(current), len(current)), nil
+				})
+			}
+		}()
+		go func() {
+			defer wait.Done()
+			for range updates {
+				items, version := registry.Snapshot()
+				if uint64(len(items)) != version {
+					t.Errorf("got %d items at version %d", len(items), version)
+				}
+				for i, item := range items {
+					if item != i {
+						t.Errorf("item %d is %d, want each update applied to the previous list", i, item)
+						return
+					}
+				}
+			}
+		}()
+	}
+	wait.Wait()
+
+	if items := registry.List(); len(items) != writers*updates || registry.Version() != writers*updates {
+		t.Errorf("got %d items at version %d, want %d", len(items), registry.Version(), writers*updates)
+	}
+}
@@ -0,0 +1,101 @@
+package roles
+
+import (
+	"path"
+	"runny-code/common"
+	"runny-code/users"
+	"strings"
+
+	"github.com/bmatcuk/doublestar"
+)
+
+// Rules limit what the users of a role can do, admins are never limited
+type Rules struct {
+	ExecuteGroups  []string `json:"executeGroups"`  // patterns of the `@group` of commands that can be executed, "*" for all, "" for commands without a group
+	ManageCommands bool     `json:"manageCommands"` // add and delete commands and their webhooks, if `ALLOW_COMMAND_MANIPULATION` allows it
+	ReadOnlyPaths  []string `json:"readOnlyPaths"`  // patterns of paths in the files directory, e.g. "backups" or "**/*.conf"
+	HiddenPaths    []string `json:"hiddenPaths"`    // like ReadOnlyPaths, and not listed or readable
+}
+
+// RolesRules of the non admin roles, loaded from the roles file
+var RolesRules = defaultRules()
+
+func defaultRules() map[string]Rules {
+	return map[string]Rules{
+		users.RoleOperator: {
+			ExecuteGroups:  []string{"*"},
+			ManageCommands: true,
+			ReadOnlyPaths:  []string{},
+			HiddenPaths:    []string{},
+		},
+		users.RoleViewer: {
+			ExecuteGroups:  []string{},
+			ManageCommands: false,
+			ReadOnlyPaths:  []string{"**"},
+			HiddenPaths:    []string{},
+		},
+	}
+}
+
+// CanExecute reports whether the role can run the commands of the group
+func CanExecute(role string, group string) bool {
+	if role == users.RoleAdmin {
+		return true
+	}
+	for _, pattern := range RolesRules[role].ExecuteGroups {
+		if matched, _ := path.Match(pattern, group); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// CanManageCommands reports whether the role can add and delete commands and webhooks
+func CanManageCommands(role string) bool {
+	if common.Allow_Command_Manipulation_Env != "true" {
+		return false
+	}
+	return role == users.RoleAdmin || RolesRules[role].ManageCommands
+}
+
+// IsHidden reports whether the path, relative to the files directory, or one of its parents is hidden from the role
+func IsHidden(role string, relativePath string) bool {
+	if role == users.RoleAdmin {
+		return false
+	}
+	return matchesPathOrParent(RolesRules[role].HiddenPaths, relativePath)
+}
+
+// IsReadOnly reports whether the path, relative to the files directory, can not be changed by the role
+func IsReadOnly(role string, relativePath string) bool {
+	if role == users.RoleAdmin {
+		return false
+	}
+	return IsHidden(role, relativePath) || matchesPathOrParent(RolesRules[role].ReadOnlyPaths, relativePath)
+}
+
+// HasPathRules reports whether any path of the files directory is read-only or hidden for the role
+func HasPathRules(role string) bool {
+	rules := RolesRules[role]
+	return role != users.RoleAdmin && len(rules.ReadOnlyPaths)+len(rules.HiddenPaths) > 0
+}
+
+// matchesPathOrParent makes a pattern of a directory apply to everything inside it
+func matchesPathOrParent(patterns []string, relativePath string) bool {
+	current := strings.Trim(path.Clean("/"+relativePath), "/")
+	if current == "" {
+		current = "."
+	}
+
+	for {
+		for _, pattern := range patterns {
+			if matched, _ := doublestar.Match(pattern, current); matched {
+				return true
+			}
+		}
+		if current == "." {
+			return false
+		}
+		current = path.Dir(current)
+	}
+}
@@ -0,0 +1,22 @@
+package roles
+
+import (
+	"encoding/json"
+	"os"
+	"runny-code/common"
+)
+
+// CreateFile creates the roles file with the default rules
+func CreateFile() error {
+	_, err := os.Stat(common.RolesFile)
+	if !os.IsNotExist(err) {
+		return err
+	}
+
+	jsonBytes, err := json.MarshalIndent(defaultRules(), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(common.RolesFile, jsonBytes, 0644)
+}
@@ -0,0 +1,52 @@
+package roles
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"runny-code/common"
+	"runny-code/users"
+
+	"github.com/bmatcuk/doublestar"
+)
+
+// ReadFile reads the rules of the non admin roles, roles missing from the file keep their defaults
+func ReadFile() (map[string]Rules, error) {
+	file, err := os.Open(common.RolesFile)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries map[string]Rules
+	err = json.NewDecoder(file).Decode(&entries)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := defaultRules()
+	for role, entry := range entries {
+		if role == users.RoleAdmin {
+			return nil, fmt.Errorf("the rules of the '%s' role can not be changed", role)
+		}
+		if !users.IsValidRole(role) {
+			return nil, fmt.Errorf("unknown role '%s'", role)
+		}
+
+		for _, pattern := range entry.ExecuteGroups {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("role '%s' has an invalid group pattern '%s'", role, pattern)
+			}
+		}
+		for _, pattern := range append(entry.ReadOnlyPaths, entry.HiddenPaths...) {
+			if _, err := doublestar.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("role '%s' has an invalid path pattern '%s'", role, pattern)
+			}
+		}
+
+		rules[role] = entry
+	}
+
+	return rules, nil
+}
@@ -0,0 +1,80 @@
+package sessions
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"sync"
+	"time"
+)
+
+// Kinds of login challenges
+const (
+	ChallengeVerify = "verify" // the user enters a TOTP or recovery code
+	ChallengeEnroll = "enroll" // 2FA is mandatory, the user confirms a new TOTP secret
+)
+
+const challengeTimeout = 5 * time.Minute
+const challengeMaxAttempts = 5
+
+// Challenge is a login that passed the password check and waits for its second factor
+// Kept in memory only, a restart just asks for the password again
+type Challenge struct {
+	Username  string
+	Kind      string
+	ExpiresAt time.Time
+	attempts  int
+}
+
+var challengesMap = map[string]*Challenge{}
+var challengesMutex sync.Mutex
+
+// CreateChallenge returns the token identifying the pending login
+func CreateChallenge(username string, kind string) (string, error) {
+	tokenBytes := make([]byte, 32)
+	_, err := rand.Read(tokenBytes)
+	if err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(tokenBytes)
+
+	challengesMutex.Lock()
+	defer challengesMutex.Unlock()
+
+	now := time.Now()
+	for key, challenge := range challengesMap {
+		if now.After(challenge.ExpiresAt) {
+			delete(challengesMap, key)
+		}
+	}
+	challengesMap[token] = &Challenge{Username: username, Kind: kind, ExpiresAt: now.Add(challengeTimeout)}
+
+	return token, nil
+}
+
+// AttemptChallenge returns the pending login and counts an attempt, the challenge is dropped after too many attempts
+func AttemptChallenge(token string) (Challenge, bool) {
+	challengesMutex.Lock()
+	defer challengesMutex.Unlock()
+
+	challenge, found := challengesMap[token]
+	if !found {
+		return Challenge{}, false
+	}
+	if time.Now().After(challenge.ExpiresAt) {
+		delete(challengesMap, token)
+		return Challenge{}, false
+	}
+
+	challenge.attempts++
+	if challenge.attempts >= challengeMaxAttempts {
+		delete(challengesMap, token)
+	}
+	return *challenge, true
+}
+
+// DeleteChallenge ends the pending login once it succeeded
+func DeleteChallenge(token string) {
+	challengesMutex.Lock()
+	defer challengesMutex.Unlock()
+	delete(challengesMap, token)
+}
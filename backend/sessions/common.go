@@ -0,0 +1,79 @@
+package sessions
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"runny-code/common"
+	"sync"
+	"time"
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+
+// Session is stored with the hash of its token, so the sessions file can not be used to log in
+type Session struct {
+	ID         string    `json:"id"`
+	TokenHash  string    `json:"tokenHash"`
+	Username   string    `json:"username"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+	ExpiresAt  time.Time `json:"expiresAt"` // absolute expiry, the idle expiry is derived from LastSeenAt
+	UserAgent  string    `json:"userAgent"`
+	IP         string    `json:"ip"`
+}
+
+// SessionInfo is the public part of a session, safe to send to the client
+type SessionInfo struct {
+	ID         string    `json:"id"`
+	Username   string    `json:"username"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+	UserAgent  string    `json:"userAgent"`
+	IP         string    `json:"ip"`
+	Current    bool      `json:"current"`
+}
+
+var sessionsList []Session
+var sessionsMutex sync.Mutex
+
+// last seen times are persisted at most this often
+const touchInterval = time.Minute
+
+func (s *Session) Info(currentID string) SessionInfo {
+	return SessionInfo{
+		ID:         s.ID,
+		Username:   s.Username,
+		CreatedAt:  s.CreatedAt,
+		LastSeenAt: s.LastSeenAt,
+		ExpiresAt:  s.ExpiresAt,
+		UserAgent:  s.UserAgent,
+		IP:         s.IP,
+		Current:    s.ID == currentID,
+	}
+}
+
+func (s *Session) isExpired(now time.Time) bool {
+	return now.After(s.ExpiresAt) || now.Sub(s.LastSeenAt) > IdleTimeout()
+}
+
+func IdleTimeout() time.Duration {
+	return common.ParseDuration(common.Session_Idle_Timeout_Env, 24*time.Hour)
+}
+
+func MaxAge() time.Duration {
+	return common.ParseDuration(common.Session_Max_Age_Env, 7*24*time.Hour)
+}
+
+// CSRFToken derives the CSRF token of a session from its secret token
+func CSRFToken(token string) string {
+	hash := sha256.Sum256([]byte("csrf:" + token))
+	return base64.RawURLEncoding.EncodeToString(hash[:])
+}
+
+func hashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
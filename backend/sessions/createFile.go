@@ -0,0 +1,14 @@
+package sessions
+
+import (
+	"os"
+	"runny-code/common"
+)
+
+func CreateFile() error {
+	_, err := os.Stat(common.SessionsFile)
+	if os.IsNotExist(err) {
+		return os.WriteFile(common.SessionsFile, []byte("[]"), 0600)
+	}
+	return err
+}
@@ -0,0 +1,146 @@
+package sessions
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"runny-code/users"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Create starts a new session for the user, returns the session and its token for the cookie
+func Create(username string, userAgent string, ip string) (Session, string, error) {
+	tokenBytes := make([]byte, 32)
+	_, err := rand.Read(tokenBytes)
+	if err != nil {
+		return Session{}, "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(tokenBytes)
+
+	now := time.Now()
+	session := Session{
+		ID:         uuid.New().String(),
+		TokenHash:  hashToken(token),
+		Username:   username,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(MaxAge()),
+		UserAgent:  userAgent,
+		IP:         ip,
+	}
+
+	sessionsMutex.Lock()
+	defer sessionsMutex.Unlock()
+
+	sessionsList = slices.DeleteFunc(sessionsList, func(s Session) bool { return s.isExpired(now) })
+	sessionsList = append(sessionsList, session)
+
+	err = writeToFile()
+	if err != nil {
+		sessionsList = sessionsList[:len(sessionsList)-1]
+		return Session{}, "", err
+	}
+
+	return session, token, nil
+}
+
+// Authenticate returns the session of the token and its user, and refreshes the idle expiry
+// Fails when the session expired or the user was removed or disabled
+func Authenticate(token string) (Session, users.User, bool) {
+	session, found := touch(hashToken(token))
+	if !found {
+		return Session{}, users.User{}, false
+	}
+
+	user, found := users.Find(session.Username)
+	if !found || user.Disabled {
+		return Session{}, users.User{}, false
+	}
+	return session, user, true
+}
+
+func touch(tokenHash string) (Session, bool) {
+	sessionsMutex.Lock()
+	defer sessionsMutex.Unlock()
+
+	index := slices.IndexFunc(sessionsList, func(s Session) bool { return s.TokenHash == tokenHash })
+	if index == -1 {
+		return Session{}, false
+	}
+
+	now := time.Now()
+	session := &sessionsList[index]
+	if session.isExpired(now) {
+		sessionsList = slices.Delete(sessionsList, index, index+1)
+		writeToFile()
+		return Session{}, false
+	}
+
+	if now.Sub(session.LastSeenAt) > min(touchInterval, IdleTimeout()/2) {
+		session.LastSeenAt = now
+		writeToFile()
+	}
+	return *session, true
+}
+
+// List returns the active sessions of the user, or of everyone when username is empty, newest first
+func List(username string, currentID string) []SessionInfo {
+	sessionsMutex.Lock()
+	defer sessionsMutex.Unlock()
+
+	now := time.Now()
+	list := []SessionInfo{}
+	for _, session := range slices.Backward(sessionsList) {
+		if session.isExpired(now) || (username != "" && session.Username != username) {
+			continue
+		}
+		list = append(list, session.Info(currentID))
+	}
+	return list
+}
+
+// Find returns a copy of the session by its ID
+func Find(id string) (Session, bool) {
+	sessionsMutex.Lock()
+	defer sessionsMutex.Unlock()
+
+	index := slices.IndexFunc(sessionsList, func(s Session) bool { return s.ID == id })
+	if index == -1 {
+		return Session{}, false
+	}
+	return sessionsList[index], true
+}
+
+// Delete revokes the session by its ID
+func Delete(id string) error {
+	return deleteWhere(func(s Session) bool { return s.ID == id })
+}
+
+// DeleteByToken revokes the session of the token, used on logout
+func DeleteByToken(token string) error {
+	tokenHash := hashToken(token)
+	return deleteWhere(func(s Session) bool { return s.TokenHash == tokenHash })
+}
+
+// DeleteForUser revokes all sessions of the user except keepID, e.g. after disabling it or changing its password
+func DeleteForUser(username string, keepID string) error {
+	err := deleteWhere(func(s Session) bool { return s.Username == username && s.ID != keepID })
+	if err == ErrSessionNotFound {
+		return nil
+	}
+	return err
+}
+
+func deleteWhere(match func(s Session) bool) error {
+	sessionsMutex.Lock()
+	defer sessionsMutex.Unlock()
+
+	length := len(sessionsList)
+	sessionsList = slices.DeleteFunc(sessionsList, match)
+	if len(sessionsList) == length {
+		return ErrSessionNotFound
+	}
+	return writeToFile()
+}
@@ -0,0 +1,35 @@
+package sessions
+
+import (
+	"encoding/json"
+	"os"
+	"runny-code/common"
+	"slices"
+	"time"
+)
+
+// ReadFile loads the sessions and drops the expired ones
+func ReadFile() error {
+	sessionsMutex.Lock()
+	defer sessionsMutex.Unlock()
+
+	file, err := os.Open(common.SessionsFile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var entries []Session
+	err = json.NewDecoder(file).Decode(&entries)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	sessionsList = slices.DeleteFunc(entries, func(session Session) bool { return session.isExpired(now) })
+
+	if len(sessionsList) != len(entries) {
+		return writeToFile()
+	}
+	return nil
+}
@@ -0,0 +1,16 @@
+package sessions
+
+import (
+	"encoding/json"
+	"runny-code/common"
+)
+
+// writeToFile persists the sessions, must be called while holding sessionsMutex
+func writeToFile() (err error) {
+	jsonBytes, err := json.Marshal(sessionsList)
+	if err != nil {
+		return
+	}
+
+	return common.WriteFileAtomic(common.SessionsFile, jsonBytes, 0600)
+}
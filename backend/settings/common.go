@@ -0,0 +1,33 @@
+package settings
+
+import "sync"
+
+// Settings changed by admins at runtime, stored in the settings file
+type Settings struct {
+	Require2FA bool `json:"require2FA"` // users without 2FA must enroll at their next login
+}
+
+var current Settings
+var settingsMutex sync.Mutex
+
+// Current returns a copy of the settings
+func Current() Settings {
+	settingsMutex.Lock()
+	defer settingsMutex.Unlock()
+	return current
+}
+
+// Update applies the change and persists it, reverts on write failure
+func Update(change func(s *Settings)) (Settings, error) {
+	settingsMutex.Lock()
+	defer settingsMutex.Unlock()
+
+	previous := current
+	change(&current)
+
+	err := writeToFile()
+	if err != nil {
+		current = previous
+	}
+	return current, err
+}
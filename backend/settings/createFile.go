@@ -0,0 +1,14 @@
+package settings
+
+import (
+	"os"
+	"runny-code/common"
+)
+
+func CreateFile() error {
+	_, err := os.Stat(common.SettingsFile)
+	if os.IsNotExist(err) {
+		return os.WriteFile(common.SettingsFile, []byte("{}"), 0644)
+	}
+	return err
+}
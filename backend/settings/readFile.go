@@ -0,0 +1,27 @@
+package settings
+
+import (
+	"encoding/json"
+	"os"
+	"runny-code/common"
+)
+
+func ReadFile() error {
+	settingsMutex.Lock()
+	defer settingsMutex.Unlock()
+
+	file, err := os.Open(common.SettingsFile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var entry Settings
+	err = json.NewDecoder(file).Decode(&entry)
+	if err != nil {
+		return err
+	}
+
+	current = entry
+	return nil
+}
@@ -0,0 +1,16 @@
+package settings
+
+import (
+	"encoding/json"
+	"runny-code/common"
+)
+
+// writeToFile persists the settings, must be called while holding settingsMutex
+func writeToFile() (err error) {
+	jsonBytes, err := json.MarshalIndent(current, "", "  ")
+	if err != nil {
+		return
+	}
+
+	return common.WriteFileAtomic(common.SettingsFile, jsonBytes, 0600)
+}
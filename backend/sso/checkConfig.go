@@ -0,0 +1,23 @@
+package sso
+
+import (
+	"fmt"
+	"runny-code/common"
+	"runny-code/users"
+)
+
+// CheckConfig validates the single sign-on envs at startup, the issuer itself is only contacted on the first sign-in
+func CheckConfig() error {
+	if !Enabled() {
+		return nil
+	}
+	if common.OIDC_Client_ID_Env == "" {
+		return fmt.Errorf("OIDC_CLIENT_ID is required when OIDC_ISSUER is set")
+	}
+	if common.OIDC_Default_Role_Env != "" && !users.IsValidRole(common.OIDC_Default_Role_Env) {
+		return fmt.Errorf("OIDC_DEFAULT_ROLE: unknown role '%s'", common.OIDC_Default_Role_Env)
+	}
+
+	_, err := parseRoleMapping(common.OIDC_Role_Mapping_Env)
+	return err
+}
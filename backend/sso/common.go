@@ -0,0 +1,34 @@
+package sso
+
+import (
+	"errors"
+	"runny-code/common"
+	"time"
+)
+
+// Provider is the `users.User.Provider` of the users signing in with OpenID Connect
+const Provider = "oidc"
+
+// loginTimeout is how long the user has to sign in at the identity provider
+const loginTimeout = 10 * time.Minute
+
+var (
+	ErrDisabled     = errors.New("single sign-on is not configured")
+	ErrUnknownLogin = errors.New("unknown or expired sign-in, please start again")
+	ErrNoUsername   = errors.New("the identity provider did not send a username")
+	ErrNoRole       = errors.New("no role is mapped to the groups of the user")
+)
+
+// Identity is the verified user of a completed sign-in
+type Identity struct {
+	Issuer   string
+	Subject  string
+	Username string
+	Groups   []string
+	Role     string
+}
+
+// Enabled reports whether single sign-on is configured with `OIDC_ISSUER`
+func Enabled() bool {
+	return common.OIDC_Issuer_Env != ""
+}
@@ -0,0 +1,50 @@
+package sso
+
+import (
+	"context"
+	"net/http"
+	"runny-code/common"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+var provider *oidc.Provider
+var providerMutex sync.Mutex
+
+var httpClient = &http.Client{Timeout: 15 * time.Second}
+
+// getProvider discovers the issuer on first use and retries after a failure, so an unreachable issuer does not stop the server
+func getProvider() (*oidc.Provider, error) {
+	providerMutex.Lock()
+	defer providerMutex.Unlock()
+
+	if provider != nil {
+		return provider, nil
+	}
+
+	// the context is kept by the provider to fetch the signing keys later on, so it must not be canceled
+	discovered, err := oidc.NewProvider(clientContext(context.Background()), common.OIDC_Issuer_Env)
+	if err != nil {
+		return nil, err
+	}
+	provider = discovered
+	return provider, nil
+}
+
+func oauthConfig(provider *oidc.Provider, redirectURL string) oauth2.Config {
+	return oauth2.Config{
+		ClientID:     common.OIDC_Client_ID_Env,
+		ClientSecret: common.OIDC_Client_Secret_Env,
+		Endpoint:     provider.Endpoint(),
+		RedirectURL:  redirectURL,
+		Scopes:       strings.Fields(common.OIDC_Scopes_Env),
+	}
+}
+
+func clientContext(ctx context.Context) context.Context {
+	return oidc.ClientContext(ctx, httpClient)
+}
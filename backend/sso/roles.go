@@ -0,0 +1,94 @@
+package sso
+
+import (
+	"fmt"
+	"runny-code/common"
+	"runny-code/users"
+	"strings"
+)
+
+// roleRank orders the roles, a user in several mapped groups gets the highest
+var roleRank = map[string]int{users.RoleViewer: 1, users.RoleOperator: 2, users.RoleAdmin: 3}
+
+// parseRoleMapping parses `group=role | group=role`, group names may contain `=` (e.g. LDAP DNs)
+func parseRoleMapping(value string) (map[string]string, error) {
+	mapping := map[string]string{}
+
+	for _, entry := range strings.Split(value, " | ") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		separator := strings.LastIndex(entry, "=")
+		if separator <= 0 {
+			return nil, fmt.Errorf("OIDC_ROLE_MAPPING: expected 'group=role', got '%s'", entry)
+		}
+
+		group, role := strings.TrimSpace(entry[:separator]), strings.TrimSpace(entry[separator+1:])
+		if !users.IsValidRole(role) {
+			return nil, fmt.Errorf("OIDC_ROLE_MAPPING: unknown role '%s' for group '%s'", role, group)
+		}
+		mapping[group] = role
+	}
+
+	return mapping, nil
+}
+
+// roleFor returns the highest role mapped to the groups, or `OIDC_DEFAULT_ROLE`
+func roleFor(groups []string) (string, error) {
+	mapping, err := parseRoleMapping(common.OIDC_Role_Mapping_Env)
+	if err != nil {
+		return "", err
+	}
+
+	role := ""
+	for _, group := range groups {
+		if mapped, found := mapping[group]; found && roleRank[mapped] > roleRank[role] {
+			role = mapped
+		}
+	}
+
+	if role == "" {
+		role = common.OIDC_Default_Role_Env
+	}
+	if role == "" {
+		return "", ErrNoRole
+	}
+	return role, nil
+}
+
+// claimValue looks up a claim, dots go into nested objects (e.g. `realm_access.roles`)
+func claimValue(claims map[string]any, name string) any {
+	if value, found := claims[name]; found {
+		return value
+	}
+
+	var current any = claims
+	for _, key := range strings.Split(name, ".") {
+		object, ok := current.(map[string]any)
+		if !ok {
+			return nil
+		}
+		current = object[key]
+	}
+	return current
+}
+
+// claimStrings accepts a list of strings or a single string
+func claimStrings(value any) []string {
+	switch value := value.(type) {
+	case string:
+		return []string{value}
+	case []any:
+		list := make([]string, 0, len(value))
+		for _, item := range value {
+			if text, ok := item.(string); ok {
+				list = append(list, text)
+			}
+		}
+		return list
+	default:
+		return nil
+	}
+}
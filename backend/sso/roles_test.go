@@ -0,0 +1,37 @@
+package sso
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseRoleMapping(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    map[string]string
+		wantErr bool
+	}{
+		{"empty", "", map[string]string{}, false},
+		{"single group", "admins=admin", map[string]string{"admins": "admin"}, false},
+		{"several groups", "admins=admin | readers = viewer", map[string]string{"admins": "admin", "readers": "viewer"}, false},
+		{"group with a bar", "dev|ops=operator | admins=admin", map[string]string{"dev|ops": "operator", "admins": "admin"}, false},
+		{"group with equal signs", "cn=ops,dc=example=operator", map[string]string{"cn=ops,dc=example": "operator"}, false},
+		{"trailing separator", "admins=admin | ", map[string]string{"admins": "admin"}, false},
+		{"missing role", "admins", nil, true},
+		{"missing group", "=admin", nil, true},
+		{"unknown role", "admins=root", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := parseRoleMapping(test.value)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("got error %v, want an error: %t", err, test.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
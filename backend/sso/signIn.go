@@ -0,0 +1,135 @@
+package sso
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"runny-code/common"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+// pendingLogin is a sign-in waiting for the callback of the identity provider
+// Kept in memory only, a restart just asks to sign in again
+type pendingLogin struct {
+	verifier    string // PKCE
+	nonce       string
+	redirectURL string
+	expiresAt   time.Time
+}
+
+var pendingLogins = map[string]pendingLogin{}
+var pendingLoginsMutex sync.Mutex
+
+// Begin starts a sign-in, returns its state and the URL of the identity provider to send the user to
+// The state must come back unchanged to `Complete`, bind it to the browser to refuse sign-ins started elsewhere
+func Begin(redirectURL string) (state string, authURL string, err error) {
+	if !Enabled() {
+		return "", "", ErrDisabled
+	}
+
+	provider, err := getProvider()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to discover the identity provider: %w", err)
+	}
+
+	state, err = randomString()
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := randomString()
+	if err != nil {
+		return "", "", err
+	}
+	login := pendingLogin{verifier: oauth2.GenerateVerifier(), nonce: nonce, redirectURL: redirectURL, expiresAt: time.Now().Add(loginTimeout)}
+
+	pendingLoginsMutex.Lock()
+	now := time.Now()
+	for key, pending := range pendingLogins {
+		if now.After(pending.expiresAt) {
+			delete(pendingLogins, key)
+		}
+	}
+	pendingLogins[state] = login
+	pendingLoginsMutex.Unlock()
+
+	config := oauthConfig(provider, redirectURL)
+	authURL = config.AuthCodeURL(state, oauth2.S256ChallengeOption(login.verifier), oidc.Nonce(nonce))
+	return state, authURL, nil
+}
+
+// Cancel drops a pending sign-in, e.g. when the identity provider returned an error
+func Cancel(state string) {
+	pendingLoginsMutex.Lock()
+	defer pendingLoginsMutex.Unlock()
+	delete(pendingLogins, state)
+}
+
+// Complete exchanges the code of the callback, verifies the ID token and maps its claims to a user and role
+// Each state is accepted once
+func Complete(ctx context.Context, state string, code string) (Identity, error) {
+	pendingLoginsMutex.Lock()
+	login, found := pendingLogins[state]
+	delete(pendingLogins, state)
+	pendingLoginsMutex.Unlock()
+
+	if !found || time.Now().After(login.expiresAt) {
+		return Identity{}, ErrUnknownLogin
+	}
+
+	provider, err := getProvider()
+	if err != nil {
+		return Identity{}, fmt.Errorf("failed to discover the identity provider: %w", err)
+	}
+
+	config := oauthConfig(provider, login.redirectURL)
+	token, err := config.Exchange(clientContext(ctx), code, oauth2.VerifierOption(login.verifier))
+	if err != nil {
+		return Identity{}, fmt.Errorf("failed to exchange the code: %w", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return Identity{}, fmt.Errorf("the identity provider did not return an ID token")
+	}
+
+	idToken, err := provider.Verifier(&oidc.Config{ClientID: common.OIDC_Client_ID_Env}).Verify(clientContext(ctx), rawIDToken)
+	if err != nil {
+		return Identity{}, fmt.Errorf("invalid ID token: %w", err)
+	}
+	if idToken.Nonce != login.nonce {
+		return Identity{}, fmt.Errorf("invalid ID token: nonce mismatch")
+	}
+
+	var claims map[string]any
+	err = idToken.Claims(&claims)
+	if err != nil {
+		return Identity{}, fmt.Errorf("invalid ID token claims: %w", err)
+	}
+
+	username, _ := claimValue(claims, common.OIDC_Username_Claim_Env).(string)
+	if username == "" {
+		return Identity{}, ErrNoUsername
+	}
+
+	groups := claimStrings(claimValue(claims, common.OIDC_Groups_Claim_Env))
+	role, err := roleFor(groups)
+	if err != nil {
+		return Identity{}, err
+	}
+
+	return Identity{Issuer: idToken.Issuer, Subject: idToken.Subject, Username: username, Groups: groups, Role: role}, nil
+}
+
+func randomString() (string, error) {
+	randomBytes := make([]byte, 32)
+	_, err := rand.Read(randomBytes)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(randomBytes), nil
+}
@@ -0,0 +1,285 @@
+package sso
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"runny-code/common"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mockIssuer is an OpenID Connect provider issuing codes for the sign-ins started by the tests
+type mockIssuer struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mutex sync.Mutex
+	codes map[string]issuedCode
+}
+
+type issuedCode struct {
+	challenge string
+	claims    map[string]any
+}
+
+func newMockIssuer(t *testing.T) *mockIssuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuer := &mockIssuer{key: key, codes: map[string]issuedCode{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"issuer":                                issuer.server.URL,
+			"authorization_endpoint":                issuer.server.URL + "/authorize",
+			"token_endpoint":                        issuer.server.URL + "/token",
+			"jwks_uri":                              issuer.server.URL + "/keys",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("GET /keys", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"alg": "RS256",
+			"use": "sig",
+			"kid": "test",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("POST /token", issuer.token)
+
+	issuer.server = httptest.NewServer(mux)
+	t.Cleanup(issuer.server.Close)
+	return issuer
+}
+
+// authorize plays the user signing in at the provider, returns the code sent back to the callback
+// The nonce of the sign-in is added to the claims unless they already have one
+func (m *mockIssuer) authorize(t *testing.T, authURL string, claims map[string]any) string {
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := parsed.Query()
+
+	if query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		t.Fatalf("sign-in does not use PKCE: %s", authURL)
+	}
+	if query.Get("nonce") == "" || query.Get("state") == "" {
+		t.Fatalf("sign-in has no nonce or state: %s", authURL)
+	}
+	if _, found := claims["nonce"]; !found {
+		claims["nonce"] = query.Get("nonce")
+	}
+
+	code := "code-" + query.Get("state")
+	m.mutex.Lock()
+	m.codes[code] = issuedCode{challenge: query.Get("code_challenge"), claims: claims}
+	m.mutex.Unlock()
+	return code
+}
+
+func (m *mockIssuer) token(w http.ResponseWriter, r *http.Request) {
+	m.mutex.Lock()
+	issued, found := m.codes[r.FormValue("code")]
+	delete(m.codes, r.FormValue("code"))
+	m.mutex.Unlock()
+
+	verifierHash := sha256.Sum256([]byte(r.FormValue("code_verifier")))
+	if !found || base64.RawURLEncoding.EncodeToString(verifierHash[:]) != issued.challenge {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"invalid_grant"}`))
+		return
+	}
+
+	claims := map[string]any{
+		"iss": m.server.URL,
+		"aud": common.OIDC_Client_ID_Env,
+		"iat": time.Now().Unix(),
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+	for name, value := range issued.claims {
+		claims[name] = value
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"access_token": "access",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     m.sign(claims),
+	})
+}
+
+// sign returns the claims as an RS256 JWT
+func (m *mockIssuer) sign(claims map[string]any) string {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "test", "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	hash := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, m.key, crypto.SHA256, hash[:])
+	if err != nil {
+		panic(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// useMockIssuer configures single sign-on against a new mock issuer
+func useMockIssuer(t *testing.T) *mockIssuer {
+	issuer := newMockIssuer(t)
+
+	common.OIDC_Issuer_Env = issuer.server.URL
+	common.OIDC_Client_ID_Env = "runny-code"
+	common.OIDC_Client_Secret_Env = "secret"
+	common.OIDC_Scopes_Env = "openid profile"
+	common.OIDC_Username_Claim_Env = "preferred_username"
+	common.OIDC_Groups_Claim_Env = "groups"
+	common.OIDC_Role_Mapping_Env = "admins=admin | cn=ops,dc=example=operator | readers=viewer"
+	common.OIDC_Default_Role_Env = ""
+
+	providerMutex.Lock()
+	provider = nil
+	providerMutex.Unlock()
+
+	t.Cleanup(func() {
+		common.OIDC_Issuer_Env = ""
+		providerMutex.Lock()
+		provider = nil
+		providerMutex.Unlock()
+	})
+	return issuer
+}
+
+func TestCompleteMapsGroupsToRole(t *testing.T) {
+	issuer := useMockIssuer(t)
+
+	tests := []struct {
+		name     string
+		groups   any
+		wantRole string
+		wantErr  error
+	}{
+		{"single group", []string{"readers"}, "viewer", nil},
+		{"highest role wins", []string{"readers", "cn=ops,dc=example", "admins"}, "admin", nil},
+		{"group with equal signs", []string{"cn=ops,dc=example"}, "operator", nil},
+		{"single string claim", "admins", "admin", nil},
+		{"no mapped group", []string{"others"}, "", ErrNoRole},
+		{"no groups claim", nil, "", ErrNoRole},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			state, authURL, err := Begin("http://runny.test/login/oidc/callback")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			claims := map[string]any{"sub": "subject-1", "preferred_username": "alice"}
+			if test.groups != nil {
+				claims["groups"] = test.groups
+			}
+			code := issuer.authorize(t, authURL, claims)
+
+			identity, err := Complete(context.Background(), state, code)
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("got error %v, want %v", err, test.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if identity.Role != test.wantRole {
+				t.Errorf("got role %s, want %s", identity.Role, test.wantRole)
+			}
+			if identity.Issuer != issuer.server.URL || identity.Subject != "subject-1" || identity.Username != "alice" {
+				t.Errorf("got identity %+v, want the issuer, subject and username of the token", identity)
+			}
+		})
+	}
+}
+
+func TestCompleteAcceptsStateOnce(t *testing.T) {
+	issuer := useMockIssuer(t)
+
+	state, authURL, err := Begin("http://runny.test/login/oidc/callback")
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := issuer.authorize(t, authURL, map[string]any{"sub": "subject-1", "preferred_username": "alice", "groups": []string{"admins"}})
+
+	if _, err := Complete(context.Background(), "unknown-state", code); !errors.Is(err, ErrUnknownLogin) {
+		t.Errorf("unknown state: got error %v, want %v", err, ErrUnknownLogin)
+	}
+	if _, err := Complete(context.Background(), state, code); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Complete(context.Background(), state, code); !errors.Is(err, ErrUnknownLogin) {
+		t.Errorf("reused state: got error %v, want %v", err, ErrUnknownLogin)
+	}
+}
+
+func TestCompleteRequiresMatchingVerifier(t *testing.T) {
+	issuer := useMockIssuer(t)
+
+	_, authURL, err := Begin("http://runny.test/login/oidc/callback")
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherState, _, err := Begin("http://runny.test/login/oidc/callback")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a code issued to another sign-in is refused by the provider since the PKCE verifier does not match
+	code := issuer.authorize(t, authURL, map[string]any{"sub": "subject-1", "preferred_username": "alice", "groups": []string{"admins"}})
+	_, err = Complete(context.Background(), otherState, code)
+	if err == nil || !strings.Contains(err.Error(), "invalid_grant") {
+		t.Errorf("got error %v, want the exchange to be refused", err)
+	}
+}
+
+func TestCompleteRequiresMatchingNonce(t *testing.T) {
+	issuer := useMockIssuer(t)
+
+	state, authURL, err := Begin("http://runny.test/login/oidc/callback")
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := issuer.authorize(t, authURL, map[string]any{"sub": "subject-1", "preferred_username": "alice", "groups": []string{"admins"}, "nonce": "replayed"})
+
+	_, err = Complete(context.Background(), state, code)
+	if err == nil || !strings.Contains(err.Error(), "nonce") {
+		t.Errorf("got error %v, want a nonce mismatch", err)
+	}
+}
+
+func TestCompleteRequiresUsername(t *testing.T) {
+	issuer := useMockIssuer(t)
+
+	state, authURL, err := Begin("http://runny.test/login/oidc/callback")
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := issuer.authorize(t, authURL, map[string]any{"sub": "subject-1", "groups": []string{"admins"}})
+
+	if _, err := Complete(context.Background(), state, code); !errors.Is(err, ErrNoUsername) {
+		t.Errorf("got error %v, want %v", err, ErrNoUsername)
+	}
+}
@@ -0,0 +1,102 @@
+package throttle
+
+import (
+	"runny-code/common"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// failures are forgotten after this long without a new one
+const forgetAfter = 30 * time.Minute
+
+type attempts struct {
+	failures    int
+	lastFailure time.Time
+	lockedUntil time.Time
+}
+
+var attemptsMap = map[string]*attempts{}
+var attemptsMutex sync.Mutex
+
+// Keys of the tracked attempts
+func IPKey(ip string) string             { return "ip:" + ip }
+func UsernameKey(username string) string { return "user:" + username }
+
+// RetryAfter returns how long the longest lockout of the keys still lasts, 0 when none is locked
+func RetryAfter(keys ...string) time.Duration {
+	attemptsMutex.Lock()
+	defer attemptsMutex.Unlock()
+
+	now := time.Now()
+	var longest time.Duration
+	for _, key := range keys {
+		entry, found := attemptsMap[key]
+		if found && entry.lockedUntil.After(now) {
+			longest = max(longest, entry.lockedUntil.Sub(now))
+		}
+	}
+	return longest
+}
+
+// Fail records a failed attempt for each key
+// Once a key reaches `LOGIN_MAX_ATTEMPTS` it is locked out, doubling with each further failure up to `LOGIN_MAX_LOCKOUT`
+// Returns the keys that got locked by this failure
+func Fail(keys ...string) (locked map[string]time.Duration) {
+	attemptsMutex.Lock()
+	defer attemptsMutex.Unlock()
+
+	now := time.Now()
+	prune(now)
+
+	maxAttempts, err := strconv.Atoi(common.Login_Max_Attempts_Env)
+	if err != nil || maxAttempts <= 0 {
+		maxAttempts = 5
+	}
+	baseLockout := common.ParseDuration(common.Login_Lockout_Env, 30*time.Second)
+	maxLockout := common.ParseDuration(common.Login_Max_Lockout_Env, 15*time.Minute)
+
+	locked = map[string]time.Duration{}
+	for _, key := range keys {
+		entry, found := attemptsMap[key]
+		if !found {
+			entry = &attempts{}
+			attemptsMap[key] = entry
+		}
+		entry.failures++
+		entry.lastFailure = now
+
+		if entry.failures < maxAttempts {
+			continue
+		}
+
+		lockout := baseLockout
+		for range min(entry.failures-maxAttempts, 16) {
+			lockout *= 2
+		}
+		lockout = min(lockout, maxLockout)
+
+		entry.lockedUntil = now.Add(lockout)
+		locked[key] = lockout
+	}
+	return locked
+}
+
+// Succeed forgets the failures of the keys
+func Succeed(keys ...string) {
+	attemptsMutex.Lock()
+	defer attemptsMutex.Unlock()
+
+	for _, key := range keys {
+		delete(attemptsMap, key)
+	}
+}
+
+// prune drops the entries that are no longer locked and failed long ago, must be called while holding attemptsMutex
+func prune(now time.Time) {
+	for key, entry := range attemptsMap {
+		if entry.lockedUntil.Before(now) && now.Sub(entry.lastFailure) > forgetAfter {
+			delete(attemptsMap, key)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package throttle
+
+import (
+	"runny-code/common"
+	"testing"
+	"time"
+)
+
+// useSettings sets the lockout env and starts without attempts
+func useSettings(t *testing.T, maxAttempts string, lockout string, maxLockout string) {
+	previous := []string{common.Login_Max_Attempts_Env, common.Login_Lockout_Env, common.Login_Max_Lockout_Env}
+	common.Login_Max_Attempts_Env, common.Login_Lockout_Env, common.Login_Max_Lockout_Env = maxAttempts, lockout, maxLockout
+
+	attemptsMutex.Lock()
+	attemptsMap = map[string]*attempts{}
+	attemptsMutex.Unlock()
+
+	t.Cleanup(func() {
+		common.Login_Max_Attempts_Env, common.Login_Lockout_Env, common.Login_Max_Lockout_Env = previous[0], previous[1], previous[2]
+	})
+}
+
+func TestFail(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxAttempts string
+		lockout     string
+		maxLockout  string
+		want        []time.Duration // lockout after each failure, 0 when not locked
+	}{
+		{"locked from the max attempts", "3", "30s", "15m", []time.Duration{0, 0, 30 * time.Second}},
+		{"lockout doubles", "2", "30s", "15m", []time.Duration{0, 30 * time.Second, time.Minute, 2 * time.Minute, 4 * time.Minute}},
+		{"lockout is capped", "1", "1m", "3m", []time.Duration{time.Minute, 2 * time.Minute, 3 * time.Minute, 3 * time.Minute}},
+		{"invalid max attempts defaults to 5", "none", "10s", "1m", []time.Duration{0, 0, 0, 0, 10 * time.Second}},
+		{"invalid durations use the defaults", "1", "soon", "later", []time.Duration{30 * time.Second, time.Minute}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			useSettings(t, test.maxAttempts, test.lockout, test.maxLockout)
+
+			for i, want := range test.want {
+				locked := Fail(IPKey("10.0.0.1"))
+				if got := locked[IPKey("10.0.0.1")]; got != want {
+					t.Errorf("failure %d locked for %s, want %s", i+1, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestFailDoesNotOverflow(t *testing.T) {
+	useSettings(t, "1", "1s", "1000h")
+
+	var locked map[string]time.Duration
+	for range 100 {
+		locked = Fail(IPKey("10.0.0.1"))
+	}
+	if got, want := locked[IPKey("10.0.0.1")], time.Second<<16; got != want {
+		t.Errorf("locked for %s after 100 failures, want %s", got, want)
+	}
+}
+
+func TestFailTracksKeysSeparately(t *testing.T) {
+	useSettings(t, "2", "30s", "15m")
+
+	Fail(IPKey("10.0.0.1"), UsernameKey("alice"))
+	locked := Fail(IPKey("10.0.0.1"), UsernameKey("bob"))
+	if _, found := locked[IPKey("10.0.0.1")]; !found || len(locked) != 1 {
+		t.Errorf("got locked keys %v, want only the IP", locked)
+	}
+	if RetryAfter(UsernameKey("alice"), UsernameKey("bob")) != 0 {
+		t.Error("expected the usernames not to be locked")
+	}
+	if retry := RetryAfter(UsernameKey("alice"), IPKey("10.0.0.1")); retry <= 0 || retry > 30*time.Second {
+		t.Errorf("retry after %s, want the lockout of the IP", retry)
+	}
+
+	Succeed(IPKey("10.0.0.1"))
+	if RetryAfter(IPKey("10.0.0.1")) != 0 {
+		t.Error("expected a success to forget the failures")
+	}
+	if locked := Fail(IPKey("10.0.0.1")); len(locked) != 0 {
+		t.Errorf("got locked keys %v, want the failures counted from zero", locked)
+	}
+}
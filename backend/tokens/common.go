@@ -0,0 +1,92 @@
+package tokens
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Prefix of every API token, makes leaked tokens easy to spot
+const Prefix = "rc_"
+
+var ErrTokenNotFound = errors.New("token not found")
+
+// Token is stored with the hash of its secret, the secret is only shown once when created
+type Token struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Username   string     `json:"username"`
+	TokenHash  string     `json:"tokenHash"`
+	Hint       string     `json:"hint"`     // the first characters of the secret, to recognize it
+	Commands   []string   `json:"commands"` // names of the commands it can execute, empty for all the role allows
+	Routes     []string   `json:"routes"`   // "METHOD /path" it can call, a trailing * matches any rest and a * method any method, empty for all
+	CreatedAt  time.Time  `json:"createdAt"`
+	ExpiresAt  *time.Time `json:"expiresAt"` // nil never expires
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+}
+
+// TokenInfo is the public part of a token, safe to send to the client
+type TokenInfo struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Username   string     `json:"username"`
+	Hint       string     `json:"hint"`
+	Commands   []string   `json:"commands"`
+	Routes     []string   `json:"routes"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	ExpiresAt  *time.Time `json:"expiresAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+}
+
+var tokensList []Token
+var tokensMutex sync.Mutex
+
+// last used times are persisted at most this often
+const touchInterval = time.Minute
+
+func (t *Token) Info() TokenInfo {
+	return TokenInfo{
+		ID:         t.ID,
+		Name:       t.Name,
+		Username:   t.Username,
+		Hint:       t.Hint,
+		Commands:   t.Commands,
+		Routes:     t.Routes,
+		CreatedAt:  t.CreatedAt,
+		ExpiresAt:  t.ExpiresAt,
+		LastUsedAt: t.LastUsedAt,
+	}
+}
+
+func (t *Token) isExpired(now time.Time) bool {
+	return t.ExpiresAt != nil && now.After(*t.ExpiresAt)
+}
+
+// AllowsRoute reports whether the token can call the route
+func (t *Token) AllowsRoute(method string, urlPath string) bool {
+	if len(t.Routes) == 0 {
+		return true
+	}
+
+	for _, route := range t.Routes {
+		routeMethod, routePath, _ := strings.Cut(route, " ")
+		if routeMethod != "*" && !strings.EqualFold(routeMethod, method) {
+			continue
+		}
+		if prefix, isPrefix := strings.CutSuffix(routePath, "*"); isPrefix && strings.HasPrefix(urlPath, prefix) {
+			return true
+		}
+		if routePath == urlPath {
+			return true
+		}
+	}
+	return false
+}
+
+func hashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
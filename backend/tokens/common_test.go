@@ -0,0 +1,34 @@
+package tokens
+
+import "testing"
+
+func TestAllowsRoute(t *testing.T) {
+	tests := []struct {
+		name   string
+		routes []string
+		method string
+		path   string
+		want   bool
+	}{
+		{"no routes allow all", nil, "DELETE", "/user/admin", true},
+		{"exact route", []string{"GET /jobs"}, "GET", "/jobs", true},
+		{"method is case insensitive", []string{"get /jobs"}, "GET", "/jobs", true},
+		{"other method", []string{"GET /jobs"}, "POST", "/jobs", false},
+		{"longer path without wildcard", []string{"GET /job"}, "GET", "/job/1", false},
+		{"wildcard path", []string{"GET /job/*"}, "GET", "/job/1/events", true},
+		{"wildcard does not match the parent", []string{"GET /job/*"}, "GET", "/job", false},
+		{"wildcard method", []string{"* /command/"}, "PUT", "/command/", true},
+		{"any of the routes", []string{"GET /jobs", "POST /command/"}, "POST", "/command/", true},
+		{"none of the routes", []string{"GET /jobs", "POST /command/"}, "DELETE", "/command/", false},
+		{"route without a path", []string{"GET"}, "GET", "/jobs", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			token := Token{Routes: test.routes}
+			if got := token.AllowsRoute(test.method, test.path); got != test.want {
+				t.Errorf("AllowsRoute(%s, %s) with routes %q is %t, want %t", test.method, test.path, test.routes, got, test.want)
+			}
+		})
+	}
+}
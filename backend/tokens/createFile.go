@@ -0,0 +1,14 @@
+package tokens
+
+import (
+	"os"
+	"runny-code/common"
+)
+
+func CreateFile() error {
+	_, err := os.Stat(common.TokensFile)
+	if os.IsNotExist(err) {
+		return os.WriteFile(common.TokensFile, []byte("[]"), 0600)
+	}
+	return err
+}
@@ -0,0 +1,161 @@
+package tokens
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"runny-code/users"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CreateInput describes a new token, a zero ExpiresIn never expires
+type CreateInput struct {
+	Name      string
+	Username  string
+	ExpiresIn time.Duration
+	Commands  []string
+	Routes    []string
+}
+
+// Create stores a new token, returns it and its secret that is not stored
+func Create(input CreateInput) (TokenInfo, string, error) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return TokenInfo{}, "", fmt.Errorf("token name is required")
+	}
+	if input.ExpiresIn < 0 {
+		return TokenInfo{}, "", fmt.Errorf("token expiry must be in the future")
+	}
+	for _, route := range input.Routes {
+		method, routePath, found := strings.Cut(route, " ")
+		if !found || method == "" || !strings.HasPrefix(routePath, "/") {
+			return TokenInfo{}, "", fmt.Errorf("invalid route '%s', expected 'METHOD /path'", route)
+		}
+	}
+
+	secretBytes := make([]byte, 32)
+	_, err := rand.Read(secretBytes)
+	if err != nil {
+		return TokenInfo{}, "", err
+	}
+	secret := Prefix + base64.RawURLEncoding.EncodeToString(secretBytes)
+
+	now := time.Now()
+	token := Token{
+		ID:        uuid.New().String(),
+		Name:      name,
+		Username:  input.Username,
+		TokenHash: hashToken(secret),
+		Hint:      secret[:len(Prefix)+4],
+		Commands:  nonNil(input.Commands),
+		Routes:    nonNil(input.Routes),
+		CreatedAt: now,
+	}
+	if input.ExpiresIn > 0 {
+		expiresAt := now.Add(input.ExpiresIn)
+		token.ExpiresAt = &expiresAt
+	}
+
+	tokensMutex.Lock()
+	defer tokensMutex.Unlock()
+
+	tokensList = append(tokensList, token)
+	err = writeToFile()
+	if err != nil {
+		tokensList = tokensList[:len(tokensList)-1]
+		return TokenInfo{}, "", err
+	}
+
+	return token.Info(), secret, nil
+}
+
+// Authenticate returns the token of the secret and its user, and records its use
+// Fails when the token expired or the user was removed or disabled
+func Authenticate(secret string) (Token, users.User, bool) {
+	token, found := touch(hashToken(secret))
+	if !found {
+		return Token{}, users.User{}, false
+	}
+
+	user, found := users.Find(token.Username)
+	if !found || user.Disabled {
+		return Token{}, users.User{}, false
+	}
+	return token, user, true
+}
+
+func touch(tokenHash string) (Token, bool) {
+	tokensMutex.Lock()
+	defer tokensMutex.Unlock()
+
+	index := slices.IndexFunc(tokensList, func(t Token) bool { return t.TokenHash == tokenHash })
+	if index == -1 {
+		return Token{}, false
+	}
+
+	now := time.Now()
+	token := &tokensList[index]
+	if token.isExpired(now) {
+		return Token{}, false
+	}
+
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) > touchInterval {
+		token.LastUsedAt = &now
+		if err := writeToFile(); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to save the last use of a token: %s\n", err)
+		}
+	}
+	return *token, true
+}
+
+// List returns the tokens of the user, or of everyone when username is empty, newest first
+func List(username string) []TokenInfo {
+	tokensMutex.Lock()
+	defer tokensMutex.Unlock()
+
+	list := []TokenInfo{}
+	for _, token := range slices.Backward(tokensList) {
+		if username == "" || token.Username == username {
+			list = append(list, token.Info())
+		}
+	}
+	return list
+}
+
+// Find returns a copy of the token by its ID
+func Find(id string) (Token, bool) {
+	tokensMutex.Lock()
+	defer tokensMutex.Unlock()
+
+	index := slices.IndexFunc(tokensList, func(t Token) bool { return t.ID == id })
+	if index == -1 {
+		return Token{}, false
+	}
+	return tokensList[index], true
+}
+
+// Delete revokes the token by its ID
+func Delete(id string) error {
+	tokensMutex.Lock()
+	defer tokensMutex.Unlock()
+
+	index := slices.IndexFunc(tokensList, func(t Token) bool { return t.ID == id })
+	if index == -1 {
+		return ErrTokenNotFound
+	}
+
+	tokensList = slices.Delete(tokensList, index, index+1)
+	return writeToFile()
+}
+
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
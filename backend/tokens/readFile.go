@@ -0,0 +1,27 @@
+package tokens
+
+import (
+	"encoding/json"
+	"os"
+	"runny-code/common"
+)
+
+func ReadFile() error {
+	tokensMutex.Lock()
+	defer tokensMutex.Unlock()
+
+	file, err := os.Open(common.TokensFile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var entries []Token
+	err = json.NewDecoder(file).Decode(&entries)
+	if err != nil {
+		return err
+	}
+
+	tokensList = entries
+	return nil
+}
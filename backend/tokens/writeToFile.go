@@ -0,0 +1,16 @@
+package tokens
+
+import (
+	"encoding/json"
+	"runny-code/common"
+)
+
+// writeToFile persists the API tokens, must be called while holding tokensMutex
+func writeToFile() (err error) {
+	jsonBytes, err := json.Marshal(tokensList)
+	if err != nil {
+		return
+	}
+
+	return common.WriteFileAtomic(common.TokensFile, jsonBytes, 0600)
+}
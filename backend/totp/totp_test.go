@@ -0,0 +1,76 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+// the secret of the RFC 6238 test vectors, "12345678901234567890" in base32
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCode(t *testing.T) {
+	// the last 6 digits of the SHA1 test vectors of RFC 6238 appendix B
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, test := range tests {
+		got, err := Code(rfcSecret, Step(time.Unix(test.unix, 0)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != test.want {
+			t.Errorf("code at %d is %s, want %s", test.unix, got, test.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := Step(now)
+	codeAt := func(step int64) string {
+		code, err := Code(rfcSecret, step)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return code
+	}
+
+	tests := []struct {
+		name     string
+		secret   string
+		code     string
+		lastStep int64
+		wantStep int64
+		wantOk   bool
+	}{
+		{"current code", rfcSecret, codeAt(current), 0, current, true},
+		{"previous code within the skew", rfcSecret, codeAt(current - 1), 0, current - 1, true},
+		{"next code within the skew", rfcSecret, codeAt(current + 1), 0, current + 1, true},
+		{"code outside of the skew", rfcSecret, codeAt(current - 2), 0, 0, false},
+		{"code with spaces", rfcSecret, codeAt(current)[:3] + " " + codeAt(current)[3:] + " ", 0, current, true},
+		{"lowercase secret", "gezdgnbvgy3tqojqgezdgnbvgy3tqojq", codeAt(current), 0, current, true},
+		{"reused code", rfcSecret, codeAt(current), current, 0, false},
+		{"code older than the last one", rfcSecret, codeAt(current - 1), current, 0, false},
+		{"wrong code", rfcSecret, "000000", 0, 0, false},
+		{"too short", rfcSecret, codeAt(current)[:5], 0, 0, false},
+		{"invalid secret", "not base32!", codeAt(current), 0, 0, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			step, ok := Validate(test.secret, test.code, now, test.lastStep)
+			if ok != test.wantOk || step != test.wantStep {
+				t.Errorf("got step %d valid %t, want step %d valid %t", step, ok, test.wantStep, test.wantOk)
+			}
+		})
+	}
+}
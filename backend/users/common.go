@@ -0,0 +1,85 @@
+package users
+
+import (
+	"errors"
+	"runny-code/identity"
+	"sync"
+	"time"
+)
+
+// Roles
+const (
+	RoleAdmin    = "admin" // everything, including managing users
+	RoleOperator = "operator"
+	RoleViewer   = "viewer"
+)
+
+// MinPasswordLength applies to created users and changed passwords
+const MinPasswordLength = 8
+
+var (
+	ErrUserNotFound       = errors.New("user not found")
+	ErrUserExists         = errors.New("user already exists")
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrLastAdmin          = errors.New("at least one enabled admin is required")
+	ErrInvalidCode        = errors.New("invalid two-factor code")
+	Err2FAEnabled         = errors.New("two-factor authentication is already enabled")
+	Err2FANotEnabled      = errors.New("two-factor authentication is not enabled")
+	ErrExternalUser       = errors.New("the user signs in with single sign-on and has no password")
+	ErrLocalUser          = errors.New("a local user with this name already exists")
+	ErrExternalUserExists = errors.New("another single sign-on account already uses this name")
+	ErrReservedUsername   = errors.New("usernames starting with '" + identity.CertificatePrefix + "' are reserved for client certificates")
+)
+
+type User struct {
+	Username     string    `json:"username"`
+	PasswordHash string    `json:"passwordHash"` // bcrypt
+	Role         string    `json:"role"`
+	Disabled     bool      `json:"disabled,omitempty"`
+	Provider     string    `json:"provider,omitempty"` // e.g. oidc for users created on their first single sign-on, they have no password
+	Issuer       string    `json:"issuer,omitempty"`   // the issuer and subject identify the account at the provider, the username may change there
+	Subject      string    `json:"subject,omitempty"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+
+	TOTPSecret    string   `json:"totpSecret,omitempty"` // set on enrollment, only checked once TOTPEnabled
+	TOTPEnabled   bool     `json:"totpEnabled,omitempty"`
+	TOTPLastStep  int64    `json:"totpLastStep,omitempty"`  // last accepted time step, so a code can not be reused
+	RecoveryCodes []string `json:"recoveryCodes,omitempty"` // sha256 hashes of the unused recovery codes
+}
+
+// UserInfo is the public part of a user, safe to send to the client
+type UserInfo struct {
+	Username  string    `json:"username"`
+	Role      string    `json:"role"`
+	Disabled  bool      `json:"disabled"`
+	Provider  string    `json:"provider,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	TwoFactorEnabled bool `json:"twoFactorEnabled"`
+}
+
+var usersList []User
+var usersMutex sync.Mutex
+
+func (u *User) Info() UserInfo {
+	return UserInfo{Username: u.Username, Role: u.Role, Disabled: u.Disabled, Provider: u.Provider, CreatedAt: u.CreatedAt, UpdatedAt: u.UpdatedAt, TwoFactorEnabled: u.TOTPEnabled}
+}
+
+// ExternalAccount is an account of an identity provider signing in
+type ExternalAccount struct {
+	Provider string
+	Issuer   string
+	Subject  string
+	Username string // used as the name of the user when it is created
+	Role     string
+}
+
+func (u *User) isAccount(account ExternalAccount) bool {
+	return u.Provider == account.Provider && u.Issuer == account.Issuer && u.Subject == account.Subject
+}
+
+func IsValidRole(role string) bool {
+	return role == RoleAdmin || role == RoleOperator || role == RoleViewer
+}
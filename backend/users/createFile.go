@@ -0,0 +1,25 @@
+package users
+
+import (
+	"os"
+	"runny-code/common"
+)
+
+// CreateFile creates the users file with the bootstrap admin from `AUTH_USERNAME` and `AUTH_PASSWORD`
+func CreateFile() error {
+	_, err := os.Stat(common.UsersFile)
+	if !os.IsNotExist(err) {
+		return err
+	}
+
+	usersMutex.Lock()
+	defer usersMutex.Unlock()
+
+	admin, err := bootstrapAdmin()
+	if err != nil {
+		return err
+	}
+	usersList = []User{admin}
+
+	return writeToFile()
+}
@@ -0,0 +1,246 @@
+package users
+
+import (
+	"fmt"
+	"runny-code/identity"
+	"slices"
+	"strings"
+	"time"
+)
+
+// List returns the public info of all users
+func List() []UserInfo {
+	usersMutex.Lock()
+	defer usersMutex.Unlock()
+
+	list := make([]UserInfo, 0, len(usersList))
+	for _, user := range usersList {
+		list = append(list, user.Info())
+	}
+	return list
+}
+
+// Find returns a copy of the user
+func Find(username string) (User, bool) {
+	usersMutex.Lock()
+	defer usersMutex.Unlock()
+
+	index := indexOf(username)
+	if index == -1 {
+		return User{}, false
+	}
+	return usersList[index], true
+}
+
+// Authenticate checks the credentials of an enabled user
+func Authenticate(username string, password string) (User, error) {
+	user, found := Find(username)
+	if !found {
+		checkPassword(string(dummyHash), password)
+		return User{}, ErrInvalidCredentials
+	}
+
+	// users of an identity provider have no password, still compare against one for the same timing
+	if user.Provider != "" {
+		checkPassword(string(dummyHash), password)
+		return User{}, ErrInvalidCredentials
+	}
+
+	if !checkPassword(user.PasswordHash, password) || user.Disabled {
+		return User{}, ErrInvalidCredentials
+	}
+	return user, nil
+}
+
+func Create(username string, password string, role string) (UserInfo, error) {
+	username = strings.TrimSpace(username)
+	if username == "" {
+		return UserInfo{}, fmt.Errorf("username is required")
+	}
+	if strings.HasPrefix(username, identity.CertificatePrefix) {
+		return UserInfo{}, ErrReservedUsername
+	}
+	if !IsValidRole(role) {
+		return UserInfo{}, fmt.Errorf("unknown role '%s'", role)
+	}
+	err := validatePassword(password)
+	if err != nil {
+		return UserInfo{}, err
+	}
+
+	hash, err := hashPassword(password)
+	if err != nil {
+		return UserInfo{}, err
+	}
+
+	usersMutex.Lock()
+	defer usersMutex.Unlock()
+
+	if indexOf(username) != -1 {
+		return UserInfo{}, ErrUserExists
+	}
+
+	now := time.Now()
+	user := User{Username: username, PasswordHash: hash, Role: role, CreatedAt: now, UpdatedAt: now}
+	usersList = append(usersList, user)
+
+	err = writeToFile()
+	if err != nil {
+		usersList = usersList[:len(usersList)-1]
+		return UserInfo{}, err
+	}
+
+	return user.Info(), nil
+}
+
+// SetDisabled disables or enables a user, the last enabled admin can not be disabled
+func SetDisabled(username string, disabled bool) error {
+	usersMutex.Lock()
+	defer usersMutex.Unlock()
+
+	index := indexOf(username)
+	if index == -1 {
+		return ErrUserNotFound
+	}
+
+	if disabled && usersList[index].Role == RoleAdmin && countEnabledAdmins() == 1 {
+		return ErrLastAdmin
+	}
+
+	return update(index, func(user *User) { user.Disabled = disabled })
+}
+
+func ChangePassword(username string, password string) error {
+	err := validatePassword(password)
+	if err != nil {
+		return err
+	}
+
+	hash, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	usersMutex.Lock()
+	defer usersMutex.Unlock()
+
+	index := indexOf(username)
+	if index == -1 {
+		return ErrUserNotFound
+	}
+	if usersList[index].Provider != "" {
+		return ErrExternalUser
+	}
+
+	return update(index, func(user *User) { user.PasswordHash = hash })
+}
+
+// SignInExternal returns the user of the account signing in with an identity provider, creating it on the first sign-in
+// Accounts are matched on their issuer and subject, the username is only taken on creation and is kept when it changes
+// at the provider, so another account can not take over a user by getting its name
+// The role follows the provider on every sign-in, local users with the same name are not taken over
+func SignInExternal(account ExternalAccount) (User, error) {
+	if account.Username == "" {
+		return User{}, fmt.Errorf("username is required")
+	}
+	if strings.HasPrefix(account.Username, identity.CertificatePrefix) {
+		return User{}, ErrReservedUsername
+	}
+	if account.Issuer == "" || account.Subject == "" {
+		return User{}, fmt.Errorf("issuer and subject are required")
+	}
+	if !IsValidRole(account.Role) {
+		return User{}, fmt.Errorf("unknown role '%s'", account.Role)
+	}
+
+	usersMutex.Lock()
+	defer usersMutex.Unlock()
+
+	index := slices.IndexFunc(usersList, func(user User) bool { return user.isAccount(account) })
+	if index == -1 {
+		index = indexOf(account.Username)
+		if index == -1 {
+			return createExternal(account)
+		}
+
+		user := usersList[index]
+		if user.Provider != account.Provider {
+			return User{}, ErrLocalUser
+		}
+		if user.Subject != "" {
+			return User{}, ErrExternalUserExists
+		}
+
+		// created before accounts were matched on their subject, bind it to the account signing in with its name
+		err := update(index, func(user *User) {
+			user.Issuer = account.Issuer
+			user.Subject = account.Subject
+		})
+		if err != nil {
+			return User{}, err
+		}
+	}
+
+	user := usersList[index]
+	if user.Disabled {
+		return User{}, ErrInvalidCredentials
+	}
+	if user.Role == account.Role {
+		return user, nil
+	}
+	if user.Role == RoleAdmin && countEnabledAdmins() == 1 {
+		return User{}, ErrLastAdmin
+	}
+
+	err := update(index, func(user *User) { user.Role = account.Role })
+	return usersList[index], err
+}
+
+// createExternal adds the user of an account signing in for the first time, must be called while holding usersMutex
+func createExternal(account ExternalAccount) (User, error) {
+	now := time.Now()
+	user := User{
+		Username:  account.Username,
+		Role:      account.Role,
+		Provider:  account.Provider,
+		Issuer:    account.Issuer,
+		Subject:   account.Subject,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	usersList = append(usersList, user)
+
+	err := writeToFile()
+	if err != nil {
+		usersList = usersList[:len(usersList)-1]
+		return User{}, err
+	}
+	return user, nil
+}
+
+// update applies the change and persists it, reverts on write failure
+// must be called while holding usersMutex
+func update(index int, change func(user *User)) error {
+	previous := usersList[index]
+	change(&usersList[index])
+	usersList[index].UpdatedAt = time.Now()
+
+	err := writeToFile()
+	if err != nil {
+		usersList[index] = previous
+	}
+	return err
+}
+
+func indexOf(username string) int {
+	return slices.IndexFunc(usersList, func(user User) bool { return user.Username == username })
+}
+
+func countEnabledAdmins() (count int) {
+	for _, user := range usersList {
+		if user.Role == RoleAdmin && !user.Disabled {
+			count++
+		}
+	}
+	return
+}
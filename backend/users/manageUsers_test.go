@@ -0,0 +1,113 @@
+package users
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// useUsers runs the test from a directory with a config directory and starts from the given users
+func useUsers(t *testing.T, entries ...User) {
+	root := t.TempDir()
+	for _, dir := range []string{"config", "backend"} {
+		if err := os.Mkdir(filepath.Join(root, dir), 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(filepath.Join(root, "backend"))
+
+	usersMutex.Lock()
+	previous := usersList
+	usersList = entries
+	usersMutex.Unlock()
+
+	t.Cleanup(func() {
+		usersMutex.Lock()
+		usersList = previous
+		usersMutex.Unlock()
+	})
+}
+
+func TestSignInExternal(t *testing.T) {
+	account := ExternalAccount{Provider: "oidc", Issuer: "https://idp", Subject: "sub-1", Username: "alice", Role: RoleOperator}
+
+	tests := []struct {
+		name     string
+		users    []User
+		account  ExternalAccount
+		wantUser string
+		wantErr  error
+	}{
+		{
+			name:     "first sign-in creates the user",
+			account:  account,
+			wantUser: "alice",
+		},
+		{
+			name:     "renamed at the provider keeps the user",
+			users:    []User{{Username: "alice", Role: RoleOperator, Provider: "oidc", Issuer: "https://idp", Subject: "sub-1"}},
+			account:  ExternalAccount{Provider: "oidc", Issuer: "https://idp", Subject: "sub-1", Username: "alice.smith", Role: RoleOperator},
+			wantUser: "alice",
+		},
+		{
+			name:    "another account with the same name is refused",
+			users:   []User{{Username: "alice", Role: RoleAdmin, Provider: "oidc", Issuer: "https://idp", Subject: "sub-1"}},
+			account: ExternalAccount{Provider: "oidc", Issuer: "https://idp", Subject: "sub-2", Username: "alice", Role: RoleViewer},
+			wantErr: ErrExternalUserExists,
+		},
+		{
+			name:    "same subject of another issuer is another account",
+			users:   []User{{Username: "alice", Role: RoleAdmin, Provider: "oidc", Issuer: "https://idp", Subject: "sub-1"}},
+			account: ExternalAccount{Provider: "oidc", Issuer: "https://other-idp", Subject: "sub-1", Username: "alice", Role: RoleViewer},
+			wantErr: ErrExternalUserExists,
+		},
+		{
+			name:    "local user is not taken over",
+			users:   []User{{Username: "alice", Role: RoleAdmin, PasswordHash: "hash"}},
+			account: account,
+			wantErr: ErrLocalUser,
+		},
+		{
+			name:     "user created without a subject is bound to it",
+			users:    []User{{Username: "alice", Role: RoleViewer, Provider: "oidc"}},
+			account:  account,
+			wantUser: "alice",
+		},
+		{
+			name:    "certificate name is refused",
+			account: ExternalAccount{Provider: "oidc", Issuer: "https://idp", Subject: "sub-1", Username: "cert:ci.internal", Role: RoleAdmin},
+			wantErr: ErrReservedUsername,
+		},
+		{
+			name:    "disabled user is refused",
+			users:   []User{{Username: "alice", Role: RoleOperator, Provider: "oidc", Issuer: "https://idp", Subject: "sub-1", Disabled: true}},
+			account: account,
+			wantErr: ErrInvalidCredentials,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			useUsers(t, test.users...)
+
+			user, err := SignInExternal(test.account)
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("got error %v, want %v", err, test.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if user.Username != test.wantUser {
+				t.Errorf("signed in as %s, want %s", user.Username, test.wantUser)
+			}
+			if user.Issuer != test.account.Issuer || user.Subject != test.account.Subject {
+				t.Errorf("user has account %s %s, want %s %s", user.Issuer, user.Subject, test.account.Issuer, test.account.Subject)
+			}
+			if user.Role != test.account.Role {
+				t.Errorf("user has role %s, want %s", user.Role, test.account.Role)
+			}
+		})
+	}
+}
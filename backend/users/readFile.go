@@ -0,0 +1,79 @@
+package users
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"runny-code/common"
+	"time"
+)
+
+// ReadFile loads the users, adds the bootstrap admin again when the file has no users
+func ReadFile() error {
+	usersMutex.Lock()
+	defer usersMutex.Unlock()
+
+	file, err := os.Open(common.UsersFile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var entries []User
+	err = json.NewDecoder(file).Decode(&entries)
+	if err != nil {
+		return err
+	}
+
+	seen := map[string]bool{}
+	seenAccounts := map[string]bool{}
+	for _, entry := range entries {
+		if entry.Username == "" {
+			return fmt.Errorf("a user is missing a username")
+		}
+		if seen[entry.Username] {
+			return fmt.Errorf("user '%s' is defined more than once", entry.Username)
+		}
+		if !IsValidRole(entry.Role) {
+			return fmt.Errorf("user '%s' has an unknown role '%s'", entry.Username, entry.Role)
+		}
+		seen[entry.Username] = true
+
+		if entry.Subject != "" {
+			account := entry.Provider + " " + entry.Issuer + " " + entry.Subject
+			if seenAccounts[account] {
+				return fmt.Errorf("user '%s' has the same single sign-on account as another user", entry.Username)
+			}
+			seenAccounts[account] = true
+		}
+	}
+
+	usersList = entries
+	if len(usersList) > 0 {
+		return nil
+	}
+
+	admin, err := bootstrapAdmin()
+	if err != nil {
+		return err
+	}
+	usersList = append(usersList, admin)
+
+	return writeToFile()
+}
+
+func bootstrapAdmin() (User, error) {
+	hash, err := hashPassword(common.Password_Env)
+	if err != nil {
+		return User{}, err
+	}
+
+	now := time.Now()
+	return User{
+		Username:     common.Username_Env,
+		PasswordHash: hash,
+		Role:         RoleAdmin,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}, nil
+}
@@ -0,0 +1,179 @@
+package users
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"runny-code/totp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// TOTPIssuer is shown by authenticator apps next to the account
+const TOTPIssuer = "Runny Code"
+
+const recoveryCodesCount = 10
+
+// BeginTOTPEnrollment stores a new secret for the user, returns it and its provisioning URI
+// Two-factor is only enabled once a code of the secret is confirmed with ConfirmTOTP
+func BeginTOTPEnrollment(username string) (secret string, uri string, err error) {
+	secret, err = totp.GenerateSecret()
+	if err != nil {
+		return
+	}
+
+	usersMutex.Lock()
+	defer usersMutex.Unlock()
+
+	index := indexOf(username)
+	if index == -1 {
+		return "", "", ErrUserNotFound
+	}
+	if usersList[index].TOTPEnabled {
+		return "", "", Err2FAEnabled
+	}
+
+	err = update(index, func(user *User) {
+		user.TOTPSecret = secret
+		user.TOTPLastStep = 0
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	return secret, totp.ProvisioningURI(TOTPIssuer, username, secret), nil
+}
+
+// ConfirmTOTP enables two-factor when the code matches the enrolled secret, returns new recovery codes
+func ConfirmTOTP(username string, code string) ([]string, error) {
+	usersMutex.Lock()
+	defer usersMutex.Unlock()
+
+	index := indexOf(username)
+	if index == -1 {
+		return nil, ErrUserNotFound
+	}
+	user := usersList[index]
+	if user.TOTPEnabled {
+		return nil, Err2FAEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, Err2FANotEnabled
+	}
+
+	step, valid := totp.Validate(user.TOTPSecret, code, time.Now(), user.TOTPLastStep)
+	if !valid {
+		return nil, ErrInvalidCode
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	err = update(index, func(user *User) {
+		user.TOTPEnabled = true
+		user.TOTPLastStep = step
+		user.RecoveryCodes = hashes
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// VerifySecondFactor accepts a TOTP code or an unused recovery code, which is then consumed
+func VerifySecondFactor(username string, code string) error {
+	usersMutex.Lock()
+	defer usersMutex.Unlock()
+
+	index := indexOf(username)
+	if index == -1 {
+		return ErrUserNotFound
+	}
+	user := usersList[index]
+	if !user.TOTPEnabled {
+		return Err2FANotEnabled
+	}
+
+	step, valid := totp.Validate(user.TOTPSecret, code, time.Now(), user.TOTPLastStep)
+	if valid {
+		return update(index, func(user *User) { user.TOTPLastStep = step })
+	}
+
+	codeHash := hashRecoveryCode(code)
+	if slices.Contains(user.RecoveryCodes, codeHash) {
+		return update(index, func(user *User) {
+			user.RecoveryCodes = slices.DeleteFunc(slices.Clone(user.RecoveryCodes), func(hash string) bool { return hash == codeHash })
+		})
+	}
+
+	return ErrInvalidCode
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of the user
+func RegenerateRecoveryCodes(username string) ([]string, error) {
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	usersMutex.Lock()
+	defer usersMutex.Unlock()
+
+	index := indexOf(username)
+	if index == -1 {
+		return nil, ErrUserNotFound
+	}
+	if !usersList[index].TOTPEnabled {
+		return nil, Err2FANotEnabled
+	}
+
+	err = update(index, func(user *User) { user.RecoveryCodes = hashes })
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// DisableTOTP removes two-factor from the user, also used by admins to reset a lost device
+func DisableTOTP(username string) error {
+	usersMutex.Lock()
+	defer usersMutex.Unlock()
+
+	index := indexOf(username)
+	if index == -1 {
+		return ErrUserNotFound
+	}
+
+	return update(index, func(user *User) {
+		user.TOTPSecret = ""
+		user.TOTPEnabled = false
+		user.TOTPLastStep = 0
+		user.RecoveryCodes = nil
+	})
+}
+
+// generateRecoveryCodes returns codes like "a1b2c-3d4e5" and their hashes to store
+func generateRecoveryCodes() (codes []string, hashes []string, err error) {
+	for range recoveryCodesCount {
+		random := make([]byte, 5)
+		_, err = rand.Read(random)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		code := hex.EncodeToString(random)
+		code = code[:5] + "-" + code[5:]
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	return
+}
+
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	hash := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(hash[:])
+}
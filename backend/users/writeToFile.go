@@ -0,0 +1,16 @@
+package users
+
+import (
+	"encoding/json"
+	"runny-code/common"
+)
+
+// writeToFile persists the users, must be called while holding usersMutex
+func writeToFile() (err error) {
+	jsonBytes, err := json.MarshalIndent(usersList, "", "  ")
+	if err != nil {
+		return
+	}
+
+	return common.WriteFileAtomic(common.UsersFile, jsonBytes, 0600)
+}
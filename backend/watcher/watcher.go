@@ -0,0 +1,148 @@
+package watcher
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runny-code/audit"
+	"runny-code/commands"
+	"runny-code/common"
+	"runny-code/webhooks"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// debounce groups the events of a save, editors and git write files in several steps
+const debounce = 300 * time.Millisecond
+
+// Start watches the config directory and reloads the commands and webhooks when their files change
+// The directory is watched rather than the files, editors often replace a file instead of writing to it
+func Start() error {
+	fileWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	err = fileWatcher.Add(common.ConfigDir)
+	if err != nil {
+		fileWatcher.Close()
+		return err
+	}
+
+	go watch(fileWatcher)
+	return nil
+}
+
+func watch(fileWatcher *fsnotify.Watcher) {
+	defer fileWatcher.Close()
+
+	commandsFiles := []string{filepath.Clean(common.CommandsFile), filepath.Clean(common.CommandsYAMLFile), filepath.Clean(common.CommandsJSONFile)}
+	webhooksFile := filepath.Clean(common.WebhooksFile)
+
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+	commandsChanged, webhooksChanged := false, false
+
+	for {
+		select {
+		case event, ok := <-fileWatcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+
+			switch name := filepath.Clean(event.Name); {
+			case slices.Contains(commandsFiles, name):
+				commandsChanged = true
+			case name == webhooksFile:
+				webhooksChanged = true
+			default:
+				continue
+			}
+			timer.Reset(debounce)
+
+		case err, ok := <-fileWatcher.Errors:
+			if !ok {
+				return
+			}
+			fmt.Fprintf(os.Stderr, "Failed to watch the config files: %s\n", err)
+
+		case <-timer.C:
+			if commandsChanged {
+				reloadCommands()
+			}
+			if webhooksChanged {
+				reloadWebhooks()
+			}
+			commandsChanged, webhooksChanged = false, false
+		}
+	}
+}
+
+// reloadCommands replaces the commands when the files parse, otherwise the previous commands are kept
+// Like at startup, the lint findings are printed but do not prevent the reload
+func reloadCommands() {
+	version := commands.Version()
+	err := commands.Update(func(current []commands.ParsedCommand) error {
+		files, err := commands.CommandsFiles()
+		if err != nil {
+			return err
+		}
+		if len(files) == 0 {
+			return errors.New("no commands file found")
+		}
+		return nil
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to reload the commands, keeping the previous ones: %s\n", err)
+		auditReload("command.reload", "commands", err, nil)
+		return
+	}
+
+	if commands.Version() != version {
+		fmt.Fprintf(os.Stderr, "Reloaded %d commands\n", len(commands.List()))
+		auditReload("command.reload", "commands", nil, map[string]string{"count": strconv.Itoa(len(commands.List()))})
+	}
+	commands.PrintFindings()
+}
+
+// reloadWebhooks replaces the webhooks when the file is valid, otherwise the previous webhooks are kept
+func reloadWebhooks() {
+	version := webhooks.Version()
+	err := webhooks.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to reload the webhooks, keeping the previous ones: %s\n", err)
+		auditReload("webhook.reload", "webhooks", err, nil)
+		return
+	}
+
+	if webhooks.Version() != version {
+		fmt.Fprintf(os.Stderr, "Reloaded %d webhooks\n", len(webhooks.List()))
+		auditReload("webhook.reload", "webhooks", nil, map[string]string{"count": strconv.Itoa(len(webhooks.List()))})
+	}
+}
+
+// auditReload logs a reload from the files edited on disk, made by no user of the app
+func auditReload(action string, target string, err error, details map[string]string) {
+	entry := audit.Entry{
+		Actor:   "watcher",
+		Action:  action,
+		Target:  target,
+		Result:  audit.ResultSuccess,
+		Details: details,
+	}
+	if err != nil {
+		entry.Result = audit.ResultFailure
+		entry.Error = err.Error()
+	}
+
+	if logErr := audit.Log(entry); logErr != nil {
+		fmt.Fprintf(os.Stderr, "failed to write audit log: %s\n", logErr)
+	}
+}
@@ -0,0 +1,73 @@
+package watcher
+
+import (
+	"os"
+	"path/filepath"
+	"runny-code/audit"
+	"runny-code/commands"
+	"runny-code/common"
+	"testing"
+)
+
+func writeCommandsFile(t *testing.T, filePath string, content string) {
+	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReloadCommandsKeepsCommandsOnlyOnParseErrors(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"config", "backend"} {
+		if err := os.Mkdir(filepath.Join(root, dir), 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(filepath.Join(root, "backend"))
+
+	// a duplicate name is a lint error, the commands are loaded as they are at startup
+	writeCommandsFile(t, common.CommandsFile, "@name Hello\necho hello\n\n@name Hello\necho hi\n")
+	reloadCommands()
+	if got := len(commands.List()); got != 2 {
+		t.Fatalf("got %d commands, want the commands with lint errors loaded", got)
+	}
+
+	writeCommandsFile(t, common.CommandsYAMLFile, "commands:\n  - name: [broken\n")
+	reloadCommands()
+	if got := len(commands.List()); got != 2 {
+		t.Errorf("got %d commands, want the previous commands kept when a file does not parse", got)
+	}
+
+	writeCommandsFile(t, common.CommandsYAMLFile, "commands:\n  - name: Bye\n    command: echo bye\n")
+	reloadCommands()
+	if got := len(commands.List()); got != 3 {
+		t.Errorf("got %d commands, want the catalog loaded once fixed", got)
+	}
+}
+
+func TestReloadCommandsIsAudited(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"config", "backend"} {
+		if err := os.Mkdir(filepath.Join(root, dir), 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(filepath.Join(root, "backend"))
+
+	writeCommandsFile(t, common.CommandsFile, "@name Hello\necho hello\n")
+	reloadCommands()
+	// unchanged, nothing to record
+	reloadCommands()
+	writeCommandsFile(t, common.CommandsYAMLFile, "commands:\n  - name: [broken\n")
+	reloadCommands()
+
+	entries, err := audit.Query(audit.Filter{Action: "command.reload"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d audit entries, want the reload and the failed reload", len(entries))
+	}
+	if entries[0].Result != audit.ResultFailure || entries[1].Result != audit.ResultSuccess || entries[1].Actor != "watcher" || entries[1].Details["count"] != "1" {
+		t.Errorf("got audit entries %+v", entries)
+	}
+}
@@ -2,28 +2,23 @@ package webhooks
 
 import (
 	"fmt"
+	"slices"
 )
 
 // AddEntry adds a new webhook
 // Adds uuid to the entry
-// Adds the entry to the WebhookEntries
-// Writes the WebhookEntries to the file
-// Returns the uuid
+// Writes the webhooks with the entry to the file
+// Then adds the entry to the webhooks
 func AddEntry(entry *WebhookEntry) (err error) {
-	var foundEntry *WebhookEntry
-	for _, e := range WebhookEntries {
-		if e.CommandName == entry.CommandName && e.Command == entry.Command {
-			foundEntry = &e
-			break
+	return update(func(current []WebhookEntry) ([]WebhookEntry, error) {
+		for _, e := range current {
+			if e.CommandName == entry.CommandName && e.Command == entry.Command {
+				return nil, fmt.Errorf("webhook for command '%s' already exists", entry.CommandName)
+			}
 		}
-	}
-	if foundEntry != nil {
-		return fmt.Errorf("webhook for command '%s' already exists", entry.CommandName)
-	}
-	
-	entry.UUID = generateUUID()
-	WebhookEntries = append(WebhookEntries, *entry)
-	err = writeToFile(WebhookEntries)
-	return err
-}
 
+		entry.UUID = generateUUID()
+		entries := append(slices.Clone(current), *entry)
+		return entries, writeToFile(entries)
+	})
+}
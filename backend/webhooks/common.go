@@ -1,6 +1,11 @@
 package webhooks
 
-import ("github.com/google/uuid")
+import (
+	"runny-code/changes"
+	"runny-code/registry"
+
+	"github.com/google/uuid"
+)
 
 type WebhookEntry struct {
 	CommandName string `json:"CommandName"`
@@ -8,8 +13,34 @@ type WebhookEntry struct {
 	UUID        string `json:"uuid"`
 }
 
-var WebhookEntries []WebhookEntry
+var entriesRegistry registry.Registry[WebhookEntry]
+
+// List returns the webhooks, the list must not be modified
+func List() []WebhookEntry {
+	return entriesRegistry.List()
+}
+
+// Version is incremented each time the webhooks change
+func Version() uint64 {
+	return entriesRegistry.Version()
+}
+
+// Load reads the webhooks file and replaces the webhooks, they are kept when the file is invalid
+func Load() error {
+	return update(func(current []WebhookEntry) ([]WebhookEntry, error) {
+		return ReadFile()
+	})
+}
+
+// update serializes the changes to the webhooks and notifies the UIs when they changed
+func update(change func(current []WebhookEntry) ([]WebhookEntry, error)) error {
+	version, changed, err := entriesRegistry.Update(change)
+	if changed {
+		changes.Publish(changes.Webhooks, version)
+	}
+	return err
+}
 
 func generateUUID() string {
 	return uuid.New().String()
-}
\ No newline at end of file
+}
@@ -3,12 +3,14 @@ package webhooks
 import "slices"
 
 func DeleteEntry(commandName string, command string) (err error) {
-	for i, entry := range WebhookEntries {
-		if entry.CommandName == commandName && entry.Command == command {
-			WebhookEntries = slices.Delete(WebhookEntries, i, i+1)
-			err = writeToFile(WebhookEntries)
-			return
+	return update(func(current []WebhookEntry) ([]WebhookEntry, error) {
+		for i, entry := range current {
+			if entry.CommandName == commandName && entry.Command == command {
+				// a new list, readers may still hold the current one
+				entries := slices.Delete(slices.Clone(current), i, i+1)
+				return entries, writeToFile(entries)
+			}
 		}
-	}
-	return
-}
\ No newline at end of file
+		return current, nil
+	})
+}
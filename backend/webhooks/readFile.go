@@ -3,6 +3,7 @@ package webhooks
 import (
 	"runny-code/common"
 	"encoding/json"
+	"fmt"
 	"os"
 )
 
@@ -18,5 +19,21 @@ func ReadFile() (entries []WebhookEntry, err error) {
 		return
 	}
 
+	err = validateEntries(entries)
 	return
-}
\ No newline at end of file
+}
+
+// validateEntries refuses entries that can not be served, e.g. from a file edited by hand
+func validateEntries(entries []WebhookEntry) error {
+	uuids := map[string]bool{}
+	for i, entry := range entries {
+		if entry.UUID == "" || entry.CommandName == "" || entry.Command == "" {
+			return fmt.Errorf("webhook %d: missing uuid, CommandName or command", i+1)
+		}
+		if uuids[entry.UUID] {
+			return fmt.Errorf("webhook %d: duplicate uuid '%s'", i+1, entry.UUID)
+		}
+		uuids[entry.UUID] = true
+	}
+	return nil
+}
@@ -1,17 +1,23 @@
 package webhooks
 
-import "fmt"
+import (
+	"fmt"
+	"slices"
+)
 
 // UpdateEntry keeps the same uuid and updates commandName and command
 func UpdateEntry(oldCommandName string, oldCommand string, newCommandName string, newCommand string) (err error) {
-	for i, entry := range WebhookEntries {
-		if entry.CommandName == oldCommandName && entry.Command == oldCommand {
-			WebhookEntries[i].CommandName = newCommandName
-			WebhookEntries[i].Command = newCommand
-			err = writeToFile(WebhookEntries)
-			return
+	return update(func(current []WebhookEntry) ([]WebhookEntry, error) {
+		for i, entry := range current {
+			if entry.CommandName == oldCommandName && entry.Command == oldCommand {
+				// a new list, readers may still hold the current one
+				entries := slices.Clone(current)
+				entries[i].CommandName = newCommandName
+				entries[i].Command = newCommand
+				return entries, writeToFile(entries)
+			}
 		}
-	}
 
-	return fmt.Errorf("webhook for command '%s' not found", oldCommandName)
+		return nil, fmt.Errorf("webhook for command '%s' not found", oldCommandName)
+	})
 }
@@ -3,9 +3,9 @@ package webhooks
 import (
 	"runny-code/common"
 	"encoding/json"
-	"os"
 )
 
+// writeToFile persists the webhooks, called by the changes to the webhooks which are serialized
 func writeToFile(entries []WebhookEntry) (err error) {
 	err = CreateFile()
 	if err != nil {
@@ -18,13 +18,5 @@ func writeToFile(entries []WebhookEntry) (err error) {
 		return
 	}
 
-	file, err := os.OpenFile(common.WebhooksFile, os.O_WRONLY|os.O_TRUNC, 0644)
-	if err != nil {
-		return
-	}
-	defer file.Close()
-
-	file.Write(jsonBytes)
-
-	return nil
+	return common.WriteFileAtomic(common.WebhooksFile, jsonBytes, 0600)
 }
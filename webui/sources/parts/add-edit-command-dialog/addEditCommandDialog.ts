@@ -3,6 +3,8 @@ import {
   deleteCommand,
   getCommandsList,
   type AddCommandInput,
+  type Command,
+  type CommandVariable,
   type CommandVariableTypes,
 } from "@scripts/api/commands";
 import { errorMsg, getElement, successMsg } from "@scripts/utils/utils";
@@ -177,8 +179,17 @@ async function addOrEditCommandBtnHandler() {
       addEditDialog.actionBtn.disabled = false;
       return;
     }
+  }
+
+  const [, err] = await addCommand(formData);
+  if (err !== null) {
+    errorMsg(err.message);
+    addEditDialog.actionBtn.disabled = false;
+    return;
+  }
 
-    // update webhook if exists (ignore errors)
+  // move the webhook if exists to the new command, the server checks access to it (ignore errors)
+  if (isEditMode) {
     const [, updateWebhookErr] = await updateWebhook(
       editingCommand!.name,
       editingCommand!.command,
@@ -188,13 +199,6 @@ async function addOrEditCommandBtnHandler() {
     if (updateWebhookErr !== null) console.error(updateWebhookErr.message);
   }
 
-  const [, err] = await addCommand(formData);
-  if (err !== null) {
-    errorMsg(err.message);
-    addEditDialog.actionBtn.disabled = false;
-    return;
-  }
-
   // refresh the command list
   const [newCommandList, getCommandsListErr] = await getCommandsList();
   if (getCommandsListErr !== null) {
@@ -221,10 +225,37 @@ function switchBetweenAddAndEdit() {
   addEditDialog.commandNameInput.value = isEditMode ? command.name : "";
   addEditDialog.groupNameInput.value = isEditMode ? command.group : "";
   addEditDialog.descriptionInput.value = isEditMode ? command.description : "";
-  addEditDialog.commandInput.value = isEditMode ? command.command : "";
+  addEditDialog.commandInput.value = isEditMode ? withInlineVariables(command) : "";
   updateCommandInputSize();
 }
 
+/**
+ * Writes the definition of each variable into its first placeholder when it is a bare `${name}`, so the variables
+ * a catalog declares in `variables` are kept when the command is saved again. commands.txt defines them there already.
+ */
+function withInlineVariables(command: Command) {
+  let text = command.command;
+  for (const variable of command.variables) {
+    if (!variable.optional && !variable.type && !variable.default && variable.values.length === 0) continue;
+
+    const name = variable.name.replace(/[.*+?^${}()|[\]\\]/g, "\\$&");
+    const first = new RegExp(`(?<!\\$)\\$\\{${name}([}?:=[])`).exec(text);
+    if (first === null || first[1] !== "}") continue;
+
+    text = text.slice(0, first.index) + placeholderOf(variable) + text.slice(first.index + first[0].length);
+  }
+  return text;
+}
+
+function placeholderOf(variable: CommandVariable) {
+  let placeholder = `\${${variable.name}`;
+  if (variable.optional) placeholder += "?";
+  if (variable.type) placeholder += `:${variable.type}`;
+  if (variable.default) placeholder += `=${variable.default}`;
+  if (variable.values.length > 0) placeholder += `[${variable.values.join("|")}]`;
+  return `${placeholder}}`;
+}
+
 /** When the user clicks on a variable on the command input, parse the variable string under the cursor and fill the form */
 function fillInsertVariableForm() {
   const cursorStart = addEditDialog.commandInput.selectionStart;
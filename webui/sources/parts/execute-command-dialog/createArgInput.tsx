@@ -15,6 +15,34 @@ export function createArgInput(cmdVar: CommandVariable) {
   return createTextInput(cmdVar);
 }
 
+/** A picker of the hosts a command can run on, the first is selected. */
+export function createHostInput(hosts: string[]) {
+  const id = "cmd-host";
+
+  const menu = (
+    <menu-component id={id} value={hosts[0]} name="host" class="cmd-input-menu" matchTriggerWidth={true}>
+      {hosts.map(host => (
+        <select-option label={host} value={host}>
+          {host}
+        </select-option>
+      ))}
+    </menu-component>
+  ) as MenuComponent;
+
+  const fragment = (
+    <>
+      <label htmlFor={id}>Host</label>
+      {menu}
+    </>
+  ) as FragmentWithInput;
+
+  fragment.onInput = (listener: (value: string) => void) => {
+    menu.addEventListener("pick", () => listener(menu.value));
+  };
+
+  return fragment;
+}
+
 function createPasswordInput(cmdVar: CommandVariable) {
   const id = `cmd-param-${cmdVar.name.toLowerCase().replace(/\s/g, "-")}`;
 
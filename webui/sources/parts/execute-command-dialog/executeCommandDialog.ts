@@ -3,7 +3,7 @@ import hljs from "highlight.js/lib/core";
 import { executeCommand, executeCommandStream, type Command } from "@scripts/api/commands";
 import { getWebhook } from "@scripts/api/webhooks";
 import { errorMsg, getElement, successMsg, wrapInQuotes } from "@scripts/utils/utils";
-import { createArgInput } from "./createArgInput";
+import { createArgInput, createHostInput } from "./createArgInput";
 import { $performingActionsOnCommand } from "~/sources/scripts/stores";
 
 export async function openExecuteCommandDialog(cmd: Command) {
@@ -13,6 +13,7 @@ export async function openExecuteCommandDialog(cmd: Command) {
   const cmdDisplay = getElement(cmdDialog, "#cmd-display");
   const cmdDesc = getElement<HTMLParagraphElement>(cmdDialog, ".cmd-desc");
   const inputsContainer = getElement<HTMLFormElement>(cmdDialog, ".cmd-dialog-inputs-container");
+  const hostContainer = getElement<HTMLDivElement>(cmdDialog, ".cmd-dialog-host-container");
   const openActionBtn = getElement<HTMLButtonElement>(cmdDialog, ".cmd-dialog-open-action-btn");
   const streamOutputToggle = getElement<ToggleCheckbox>(cmdDialog, "#cmd-stream-output-toggle");
 
@@ -24,7 +25,8 @@ export async function openExecuteCommandDialog(cmd: Command) {
 
   const args: { [key: string]: string } = {}; // to store the args from the inputs
   const formateCmd = () => {
-    return cmdStr.replace(/\$\{(.+?)([:=?[].*?)?\}/g, (_, key) => {
+    return cmdStr.replace(/(\$?)\$\{(.+?)([:=?[].*?)?\}/g, (placeholder, escape, key) => {
+      if (escape) return placeholder.slice(1); // `$${...}` is passed to the shell as `${...}`
       const value = args[key];
       if (!value) return "";
       return wrapInQuotes(value);
@@ -50,6 +52,16 @@ export async function openExecuteCommandDialog(cmd: Command) {
     inputsContainer.appendChild(inputFrag);
   }
 
+  // choose the host when the command lists several, otherwise the server uses the first
+  let host = "";
+  hostContainer.innerHTML = "";
+  if (cmd.hosts && cmd.hosts.length > 1) {
+    host = cmd.hosts[0];
+    const hostFrag = createHostInput(cmd.hosts);
+    hostFrag.onInput(value => (host = value));
+    hostContainer.appendChild(hostFrag);
+  }
+
   // display the command
   cmdDisplay.innerHTML = hljs.highlight(formateCmd(), { language: "bash" }).value;
 
@@ -77,7 +89,7 @@ export async function openExecuteCommandDialog(cmd: Command) {
     const useStreamOutput = streamOutputToggle.checked;
 
     if (!useStreamOutput) {
-      const [output, error] = await executeCommand(cmd.command, cmd.name, args);
+      const [output, error] = await executeCommand(cmd.command, cmd.name, args, host);
       if (error !== null) return onDone(error.message, false);
       if (output === "") return onDone("Command execution failed", false);
 
@@ -87,7 +99,7 @@ export async function openExecuteCommandDialog(cmd: Command) {
       return;
     }
 
-    const [response, error] = await executeCommandStream(cmd.command, cmd.name, args);
+    const [response, error] = await executeCommandStream(cmd.command, cmd.name, args, host);
     if (error !== null) return onDone(error.message, false);
 
     const reader = response.body?.getReader();
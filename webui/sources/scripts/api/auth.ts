@@ -1,7 +1,37 @@
 import { baseUrl, safeFetch } from "./common";
 
-export function login(formData: FormData): Promise<[string, null] | [null, Error]> {
-  return safeFetch("text", `${baseUrl}/login`, { method: "POST", body: formData, credentials: "include" }, "Failed to login.");
+export type TwoFactorChallenge = {
+  twoFactor: "verify" | "enroll";
+  challenge: string;
+  secret?: string;
+  uri?: string;
+};
+
+/** Resolves to `null` when logged in, or to the challenge of the second step when 2FA is needed. */
+export async function login(formData: FormData): Promise<[TwoFactorChallenge | null, null] | [null, Error]> {
+  const [res, err] = await safeFetch(
+    "response",
+    `${baseUrl}/login`,
+    { method: "POST", body: formData, credentials: "include" },
+    "Failed to login."
+  );
+  if (err !== null) return [null, err];
+
+  if (res.headers.get("Content-Type") !== "application/json") return [null, null];
+  return [(await res.json()) as TwoFactorChallenge, null];
+}
+
+export function loginTwoFactor(formData: FormData): Promise<[{ recoveryCodes: string[] }, null] | [null, Error]> {
+  return safeFetch(
+    "json",
+    `${baseUrl}/login/2fa`,
+    { method: "POST", body: formData, credentials: "include" },
+    "Failed to verify the code."
+  );
+}
+
+export function getLoginMethods(): Promise<[{ password: boolean; oidc: boolean }, null] | [null, Error]> {
+  return safeFetch("json", `${baseUrl}/login/methods`, { credentials: "include" }, "Failed to get the login methods.");
 }
 
 export async function isLoggedIn() {
@@ -0,0 +1,11 @@
+import { baseUrl } from "./common";
+
+export type ChangeType = "commands" | "webhooks";
+
+/** Calls `onChange` when the commands or webhooks change on the server, e.g. their files were edited on disk. */
+export function subscribeToChanges(onChange: (type: ChangeType) => void) {
+  const events = new EventSource(`${baseUrl}/changes`, { withCredentials: true });
+  events.addEventListener("commands", () => onChange("commands"));
+  events.addEventListener("webhooks", () => onChange("webhooks"));
+  return () => events.close();
+}
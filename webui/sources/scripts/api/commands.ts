@@ -27,6 +27,7 @@ export type Command = {
   description: string;
   group: string;
   command: string;
+  hosts: string[] | null; // from `@host`, the first is used when none is chosen
   variables: CommandVariable[];
 };
 
@@ -39,19 +40,19 @@ export function getCommandsList(): Promise<[null, Error] | [Command[], null]> {
   );
 }
 
-export function executeCommand(command: string, name: string, args: { [key: string]: string }) {
+export function executeCommand(command: string, name: string, args: { [key: string]: string }, host = "") {
   return safeFetch(
     "text",
-    `${baseUrl}/command/?command=${encodeURIComponent(command)}&name=${encodeURIComponent(name)}&streamOutput=false`,
+    `${baseUrl}/command/?command=${encodeURIComponent(command)}&name=${encodeURIComponent(name)}&streamOutput=false${host ? `&host=${encodeURIComponent(host)}` : ""}`,
     { method: "POST", body: JSON.stringify(args), credentials: "include" },
     `Failed to execute command "${command}"`
   );
 }
 
-export function executeCommandStream(command: string, name: string, args: { [key: string]: string }) {
+export function executeCommandStream(command: string, name: string, args: { [key: string]: string }, host = "") {
   return safeFetch(
     "response",
-    `${baseUrl}/command/?command=${encodeURIComponent(command)}&name=${encodeURIComponent(name)}&streamOutput=true`,
+    `${baseUrl}/command/?command=${encodeURIComponent(command)}&name=${encodeURIComponent(name)}&streamOutput=true${host ? `&host=${encodeURIComponent(host)}` : ""}`,
     { method: "POST", body: JSON.stringify(args), credentials: "include" },
     `Failed to execute command "${command}"`
   );
@@ -1,5 +1,21 @@
 export const baseUrl = _production ? "" : "http://192.168.1.111:8080";
 
+/** Adds the CSRF token set by the server in the `csrf_token` cookie to requests that change state. */
+function withCsrfToken(init?: RequestInit): RequestInit | undefined {
+  const method = (init?.method ?? "GET").toUpperCase();
+  if (method === "GET" || method === "HEAD") return init;
+
+  const token = document.cookie
+    .split("; ")
+    .find(cookie => cookie.startsWith("csrf_token="))
+    ?.slice("csrf_token=".length);
+  if (!token) return init;
+
+  const headers = new Headers(init?.headers);
+  headers.set("X-CSRF-Token", token);
+  return { ...init, headers };
+}
+
 export async function safeFetch(
   outputType: "response",
   input: RequestInfo | URL,
@@ -25,7 +41,7 @@ export async function safeFetch<T extends Response | string>(
   errorMessage?: string
 ): Promise<[T, null] | [null, Error]> {
   try {
-    const res = await fetch(input, init);
+    const res = await fetch(input, withCsrfToken(init));
     if (!res.ok) throw new Error(await res.text());
 
     if (outputType === "response") {
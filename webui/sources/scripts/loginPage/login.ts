@@ -1,9 +1,10 @@
-import { login } from "@scripts/api/auth";
-import { errorMsg } from "@scripts/utils/utils";
+import { getLoginMethods, login, loginTwoFactor, type TwoFactorChallenge } from "@scripts/api/auth";
+import { errorMsg, getElement } from "@scripts/utils/utils";
 import { initColorScheme } from "@parts/color-scheme-btn/colorScheme";
 
 initColorScheme();
 loginHandler();
+ssoHandler();
 
 function loginHandler() {
   const form = document.querySelector<HTMLFormElement>("#loginForm");
@@ -16,14 +17,67 @@ function loginHandler() {
     event.preventDefault();
 
     const formData = new FormData(form!);
-    const [, err] = await login(formData);
+    const [challenge, err] = await login(formData);
 
     if (err !== null) {
       errorMsg(err.message);
       return;
     }
 
+    if (challenge !== null) {
+      form.hidden = true;
+      twoFactorHandler(challenge);
+      return;
+    }
+
     // redirect to home page
     window.location.href = "/";
   });
 }
+
+/** Shows the single sign-on button when configured, and the error of a failed sign-in sent back in `?error=`. */
+async function ssoHandler() {
+  const ssoError = new URLSearchParams(window.location.search).get("error");
+  if (ssoError) {
+    errorMsg(ssoError);
+    window.history.replaceState(null, "", window.location.pathname);
+  }
+
+  const [methods, err] = await getLoginMethods();
+  if (err !== null) return;
+
+  getElement("#ssoLogin").hidden = !methods.oidc;
+}
+
+function twoFactorHandler(challenge: TwoFactorChallenge) {
+  const form = getElement<HTMLFormElement>("#twoFactorForm");
+  form.querySelector<HTMLInputElement>("input[name=challenge]")!.value = challenge.challenge;
+  form.hidden = false;
+
+  if (challenge.twoFactor === "enroll") {
+    getElement("#twoFactorEnroll").hidden = false;
+    getElement("#twoFactorSecret").textContent = challenge.secret ?? "";
+    getElement<HTMLAnchorElement>("#twoFactorUri").href = challenge.uri ?? "#";
+  }
+
+  form.addEventListener("submit", async event => {
+    event.preventDefault();
+
+    const [result, err] = await loginTwoFactor(new FormData(form));
+    if (err !== null) {
+      errorMsg(err.message);
+      return;
+    }
+
+    if (result.recoveryCodes.length === 0) {
+      window.location.href = "/";
+      return;
+    }
+
+    // shown once after enrolling
+    form.hidden = true;
+    getElement("#recoveryCodes").hidden = false;
+    getElement("#recoveryCodesList").textContent = result.recoveryCodes.join("\n");
+    getElement("#recoveryCodesContinue").addEventListener("click", () => (window.location.href = "/"));
+  });
+}
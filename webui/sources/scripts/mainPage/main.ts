@@ -6,6 +6,7 @@ import { initCommandsMenu } from "@parts/commands-menu/initCommandsMenu";
 import { confirmDialog, initConfirmDialog } from "@parts/confirm-dialog/confirmDialog";
 import { initFileNavigator } from "@parts/file-navigator/fileNavigator";
 import { isLoggedIn } from "@scripts/api/auth";
+import { subscribeToChanges } from "@scripts/api/changes";
 import { getCommandsList, isCommandManipulationAllowed } from "@scripts/api/commands";
 import { readDir, writeFile } from "@scripts/api/files";
 import { $commands, $files, $isCommandManipulationAllowed, $selectedFilePath } from "@scripts/stores";
@@ -46,9 +47,22 @@ initSaveFileBtn();
 initCommandActionsDialog();
 initAddEditCommandDialog();
 initConfirmDialog();
+subscribeToChanges(reloadCommandsList);
 
 // ----
 
+async function reloadCommandsList(type: "commands" | "webhooks") {
+  if (type !== "commands") return;
+
+  const [commandsList, error] = await getCommandsList();
+  if (error !== null) {
+    errorMsg(error.message);
+    return;
+  }
+
+  $commands.set(commandsList);
+}
+
 function initSaveFileBtn() {
   const saveButton = getElement<HTMLButtonElement>("#save");
   saveButton.addEventListener("click", saveFileBtnHandler);